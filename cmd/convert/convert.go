@@ -0,0 +1,192 @@
+package convert
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/naser-989/xray-knife/v3/cmd/subs"
+	"github.com/naser-989/xray-knife/v3/pkg"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/naser-989/xray-knife/v3/utils"
+	"github.com/naser-989/xray-knife/v3/utils/customlog"
+	"github.com/spf13/cobra"
+)
+
+// Config holds the configuration for the convert command
+type Config struct {
+	ConfigLinksFile string
+	SubscriptionURL string
+	ReadFromSTDIN   bool
+	Format          string
+	OutputFile      string
+	ListenAddr      string
+	ListenPort      uint16
+	ProbeURL        string
+	InsecureTLS     bool
+}
+
+// ConvertCommand encapsulates the convert command functionality
+type ConvertCommand struct {
+	config *Config
+}
+
+// NewConvertCommand creates a new instance of the convert command
+func NewConvertCommand() *cobra.Command {
+	cc := &ConvertCommand{
+		config: &Config{},
+	}
+	return cc.createCommand()
+}
+
+// ConvertCmd represents the convert command
+var ConvertCmd = NewConvertCommand()
+
+// createCommand creates and configures the cobra command
+func (cc *ConvertCommand) createCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Converts config links into a Clash, sing-box or xray config file",
+		Long: `Convert command formats (--to):
+  clash: Clash / Mihomo proxies list
+  singbox: complete sing-box config (outbounds, selector & urltest groups, mixed inbound)
+  xray: complete xray config (outbounds, observatory & leastPing balancer, socks inbound)`,
+		RunE: cc.runCommand,
+	}
+
+	cc.addFlags(cmd)
+	return cmd
+}
+
+// addFlags adds command-line flags to the command
+func (cc *ConvertCommand) addFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.StringVarP(&cc.config.ConfigLinksFile, "file", "f", "", "Read config links from a file (links, xray/sing-box JSON, Clash YAML or wg-quick config)")
+	flags.StringVarP(&cc.config.SubscriptionURL, "url", "u", "", "Read config links from a subscription url")
+	flags.BoolVarP(&cc.config.ReadFromSTDIN, "stdin", "i", false, "Read config links from STDIN")
+	flags.StringVarP(&cc.config.Format, "to", "t", "clash", "Output format (clash, singbox, xray)")
+	flags.StringVarP(&cc.config.OutputFile, "out", "o", "", "Output file (default clash.yaml, singbox.json or xray.json)")
+	flags.StringVarP(&cc.config.ListenAddr, "addr", "a", "127.0.0.1", "Listen ip address of the generated inbound")
+	flags.Uint16VarP(&cc.config.ListenPort, "port", "p", 9999, "Listen port number of the generated inbound")
+	flags.StringVar(&cc.config.ProbeURL, "probe-url", "https://www.gstatic.com/generate_204", "The url used by urltest / observatory to probe the outbounds")
+	flags.BoolVarP(&cc.config.InsecureTLS, "insecure", "e", false, "Insecure tls connection (fake SNI)")
+}
+
+// runCommand executes the convert command logic
+func (cc *ConvertCommand) runCommand(cmd *cobra.Command, args []string) error {
+	if cc.config.ConfigLinksFile == "" && cc.config.SubscriptionURL == "" && !cc.config.ReadFromSTDIN {
+		return cmd.Help()
+	}
+
+	links, err := cc.readLinks()
+	if err != nil {
+		return fmt.Errorf("failed to read config links: %w", err)
+	}
+
+	var content []byte
+	var outputFile string
+	switch cc.config.Format {
+	case "clash":
+		content, err = cc.toClash(links)
+		outputFile = "clash.yaml"
+	case "singbox":
+		content, err = cc.toSingbox(links)
+		outputFile = "singbox.json"
+	case "xray":
+		content, err = cc.toXray(links)
+		outputFile = "xray.json"
+	default:
+		return fmt.Errorf("unknown output format %q", cc.config.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cc.config.OutputFile != "" {
+		outputFile = cc.config.OutputFile
+	}
+	if err = utils.WriteIntoFile(outputFile, content); err != nil {
+		return fmt.Errorf("failed to save the config: %w", err)
+	}
+
+	customlog.Printf(customlog.Success, "Config has been saved into %s file\n", outputFile)
+	return nil
+}
+
+// readLinks collects the config links from the selected input
+func (cc *ConvertCommand) readLinks() ([]string, error) {
+	var links []string
+	var err error
+
+	switch {
+	case cc.config.ConfigLinksFile != "":
+		links, err = pkg.ReadConfigLinks(cc.config.ConfigLinksFile)
+	case cc.config.SubscriptionURL != "":
+		sub := subs.Subscription{Url: cc.config.SubscriptionURL}
+		links, err = sub.FetchAll()
+	default:
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			links = append(links, scanner.Text())
+		}
+		err = scanner.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, link := range links {
+		if link = strings.TrimSpace(link); link != "" {
+			result = append(result, link)
+		}
+	}
+	return result, nil
+}
+
+// parseLinks parses the links and compiles them with the builder (a core), when it isn't nil.
+// Links that fail are reported and skipped.
+func parseLinks(links []string, builder protocol.Builder) []protocol.Protocol {
+	var protocols []protocol.Protocol
+	for _, link := range links {
+		p, err := parseLink(link, builder)
+		if err != nil {
+			customlog.Printf(customlog.Failure, "Skipped %s: %v\n", protocol.RedactLink(link), err)
+			continue
+		}
+		protocols = append(protocols, p)
+	}
+	return protocols
+}
+
+func parseLink(link string, builder protocol.Builder) (protocol.Protocol, error) {
+	p, err := protocol.CreateProtocol(link)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.Parse(); err != nil {
+		return nil, err
+	}
+	if builder == nil {
+		return p, nil
+	}
+	return builder.Build(p)
+}
+
+// uniqueName returns a name that isn't in used yet, falling back to proxy-N for empty remarks
+func uniqueName(used map[string]bool, remark string, i int) string {
+	name := strings.TrimSpace(remark)
+	if name == "" {
+		name = fmt.Sprintf("proxy-%d", i+1)
+	}
+
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s-%d", name, n)
+	}
+	used[unique] = true
+	return unique
+}
@@ -0,0 +1,72 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/naser-989/xray-knife/v3/pkg/clash"
+	"github.com/sagernet/sing-box/option"
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+var testLinks = []string{
+	"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=ws&security=tls&path=%2Fws&host=cdn.example.com&sni=example.com#ws",
+	"vless://0090bbba-1118-46ca-87a1-52599cee74ab@1.2.3.4:443?type=tcp&security=reality&flow=xtls-rprx-vision&sni=www.speedtest.net&pbk=7xhH4b_VkliBxGulljcyPOH-bYUA2dl-XAdZAsfhk2U&sid=6ba85179e30d4fc2&fp=chrome#reality",
+	"trojan://secret@example.com:443?security=tls&type=grpc&serviceName=grpc&sni=example.com#trojan",
+	"ss://YWVzLTEyOC1nY206cGFzcw@1.2.3.4:8388#ss",
+}
+
+func newTestCommand() *ConvertCommand {
+	return &ConvertCommand{config: &Config{
+		ListenAddr: "127.0.0.1",
+		ListenPort: 9999,
+		ProbeURL:   "https://www.gstatic.com/generate_204",
+	}}
+}
+
+func TestConvertCommand_toClash(t *testing.T) {
+	content, err := newTestCommand().toClash(testLinks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The Clash importer must read back every proxy
+	protocols, errs := clash.LoadProxies(content)
+	if len(errs) != 0 || len(protocols) != len(testLinks) {
+		t.Errorf("expected %d proxies, got %d (errors: %v)\n%s", len(testLinks), len(protocols), errs, content)
+	}
+}
+
+func TestConvertCommand_toSingbox(t *testing.T) {
+	content, err := newTestCommand().toSingbox(testLinks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var options option.Options
+	if err = options.UnmarshalJSON(content); err != nil {
+		t.Fatalf("generated config is invalid: %v\n%s", err, content)
+	}
+	// selector + urltest + outbounds + direct
+	if len(options.Outbounds) != len(testLinks)+3 {
+		t.Errorf("expected %d outbounds, got %d", len(testLinks)+3, len(options.Outbounds))
+	}
+}
+
+func TestConvertCommand_toXray(t *testing.T) {
+	content, err := newTestCommand().toXray(testLinks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config conf.Config
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&config); err != nil {
+		t.Fatalf("generated config can't be decoded: %v\n%s", err, content)
+	}
+	if _, err = config.Build(); err != nil {
+		t.Fatalf("generated config is invalid: %v\n%s", err, content)
+	}
+}
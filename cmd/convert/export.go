@@ -0,0 +1,251 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/naser-989/xray-knife/v3/pkg"
+	"github.com/naser-989/xray-knife/v3/pkg/clash"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/naser-989/xray-knife/v3/pkg/singbox"
+	"github.com/naser-989/xray-knife/v3/pkg/xray"
+	"github.com/naser-989/xray-knife/v3/utils/customlog"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"gopkg.in/yaml.v3"
+)
+
+var errNoOutbounds = errors.New("none of the config links could be converted")
+
+// toClash builds a Clash proxies list.
+// Links are parsed into the same protocol structs the Clash importer produces.
+func (cc *ConvertCommand) toClash(links []string) ([]byte, error) {
+	// Clash has its own proxy format, so the links don't go through a core
+	protocols := parseLinks(links, nil)
+
+	var config clash.Config
+	used := map[string]bool{}
+	for i, p := range protocols {
+		proxy, err := clash.FromProtocol(p)
+		if err != nil {
+			customlog.Printf(customlog.Failure, "Skipped %s: %v\n", protocol.RedactLink(p.ConvertToGeneralConfig().OrigLink), err)
+			continue
+		}
+		proxy.Name = uniqueName(used, proxy.Name, i)
+		config.Proxies = append(config.Proxies, *proxy)
+	}
+	if len(config.Proxies) == 0 {
+		return nil, errNoOutbounds
+	}
+
+	return yaml.Marshal(config)
+}
+
+// toSingbox builds a complete sing-box config: a mixed inbound, every outbound
+// grouped under a urltest and a selector, and a route that sends everything to the selector.
+func (cc *ConvertCommand) toSingbox(links []string) ([]byte, error) {
+	core := pkg.CoreFactory(pkg.SingboxCoreType, cc.config.InsecureTLS, false)
+	protocols := parseLinks(links, core)
+
+	var outbounds []option.Outbound
+	var tags []string
+	used := map[string]bool{}
+	for i, p := range protocols {
+		// Same options the core crafts when testing the config, detours (e.g. ShadowTLS) included
+		tag := uniqueName(used, p.ConvertToGeneralConfig().Remark, i)
+		chain, err := singbox.CraftChainOptions(p.(singbox.Protocol), tag, cc.config.InsecureTLS)
+		if err != nil {
+			customlog.Printf(customlog.Failure, "Skipped %s: %v\n", protocol.RedactLink(p.ConvertToGeneralConfig().OrigLink), err)
+			continue
+		}
+		outbounds = append(outbounds, chain...)
+		tags = append(tags, tag)
+	}
+	if len(outbounds) == 0 {
+		return nil, errNoOutbounds
+	}
+
+	listen, err := netip.ParseAddr(cc.config.ListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address: %w", err)
+	}
+
+	groups := []option.Outbound{
+		{
+			Type: C.TypeSelector,
+			Tag:  "select",
+			SelectorOptions: option.SelectorOutboundOptions{
+				Outbounds: append([]string{"auto"}, tags...),
+				Default:   "auto",
+			},
+		},
+		{
+			Type: C.TypeURLTest,
+			Tag:  "auto",
+			URLTestOptions: option.URLTestOutboundOptions{
+				Outbounds: tags,
+				URL:       cc.config.ProbeURL,
+				Interval:  option.Duration(3 * time.Minute),
+			},
+		},
+	}
+	outbounds = append(groups, outbounds...)
+	outbounds = append(outbounds, option.Outbound{Type: C.TypeDirect, Tag: "direct"})
+
+	options := option.Options{
+		Log: &option.LogOptions{Level: "warn"},
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-in",
+				MixedOptions: option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     option.NewListenAddress(listen),
+						ListenPort: cc.config.ListenPort,
+					},
+				},
+			},
+		},
+		Outbounds: outbounds,
+		Route:     &option.RouteOptions{Final: "select", AutoDetectInterface: true},
+	}
+
+	return json.MarshalIndent(options, "", "  ")
+}
+
+// Configs of the xray sections that have no marshallable type in infra/conf
+type xrayInbound struct {
+	Tag      string      `json:"tag"`
+	Protocol string      `json:"protocol"`
+	Listen   string      `json:"listen"`
+	Port     uint16      `json:"port"`
+	Settings interface{} `json:"settings"`
+}
+
+type xrayObservatory struct {
+	SubjectSelector   []string `json:"subjectSelector"`
+	ProbeURL          string   `json:"probeURL"`
+	ProbeInterval     string   `json:"probeInterval"`
+	EnableConcurrency bool     `json:"enableConcurrency"`
+}
+
+type xrayBalancer struct {
+	Tag      string            `json:"tag"`
+	Selector []string          `json:"selector"`
+	Strategy map[string]string `json:"strategy"`
+}
+
+type xrayRule struct {
+	Type        string `json:"type"`
+	Network     string `json:"network"`
+	BalancerTag string `json:"balancerTag"`
+}
+
+type xrayRouting struct {
+	DomainStrategy string         `json:"domainStrategy"`
+	Balancers      []xrayBalancer `json:"balancers"`
+	Rules          []xrayRule     `json:"rules"`
+}
+
+type xrayConfig struct {
+	Log         map[string]string `json:"log"`
+	Inbounds    []xrayInbound     `json:"inbounds"`
+	Outbounds   []interface{}     `json:"outbounds"`
+	Observatory xrayObservatory   `json:"observatory"`
+	Routing     xrayRouting       `json:"routing"`
+}
+
+// toXray builds a complete xray config: a socks inbound and every outbound
+// behind a leastPing balancer fed by the observatory.
+func (cc *ConvertCommand) toXray(links []string) ([]byte, error) {
+	core := pkg.CoreFactory(pkg.XrayCoreType, cc.config.InsecureTLS, false)
+	protocols := parseLinks(links, core)
+
+	var outbounds []interface{}
+	for _, p := range protocols {
+		// Same detour config the core builds when testing the config
+		out, err := p.(xray.Protocol).BuildOutboundDetourConfig(cc.config.InsecureTLS)
+		if err != nil {
+			customlog.Printf(customlog.Failure, "Skipped %s: %v\n", protocol.RedactLink(p.ConvertToGeneralConfig().OrigLink), err)
+			continue
+		}
+		// Balancer selectors match tag prefixes, so remarks can't be used as tags
+		out.Tag = fmt.Sprintf("proxy-%d", len(outbounds)+1)
+
+		compact, err := compactJSON(out)
+		if err != nil {
+			return nil, err
+		}
+		outbounds = append(outbounds, compact)
+	}
+	if len(outbounds) == 0 {
+		return nil, errNoOutbounds
+	}
+	outbounds = append(outbounds, map[string]string{"tag": "direct", "protocol": "freedom"})
+
+	config := xrayConfig{
+		Log: map[string]string{"loglevel": "warning"},
+		Inbounds: []xrayInbound{
+			{
+				Tag:      "socks-in",
+				Protocol: "socks",
+				Listen:   cc.config.ListenAddr,
+				Port:     cc.config.ListenPort,
+				Settings: map[string]interface{}{"auth": "noauth", "udp": true},
+			},
+		},
+		Outbounds: outbounds,
+		Observatory: xrayObservatory{
+			SubjectSelector:   []string{"proxy-"},
+			ProbeURL:          cc.config.ProbeURL,
+			ProbeInterval:     "3m",
+			EnableConcurrency: true,
+		},
+		Routing: xrayRouting{
+			DomainStrategy: "AsIs",
+			Balancers: []xrayBalancer{
+				{Tag: "balancer", Selector: []string{"proxy-"}, Strategy: map[string]string{"type": "leastPing"}},
+			},
+			Rules: []xrayRule{
+				{Type: "field", Network: "tcp,udp", BalancerTag: "balancer"},
+			},
+		},
+	}
+
+	return json.MarshalIndent(config, "", "  ")
+}
+
+// compactJSON round-trips v through JSON and drops the null fields
+// that the infra/conf structs are full of
+func compactJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err = json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return dropNulls(generic), nil
+}
+
+func dropNulls(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, field := range value {
+			if field == nil {
+				delete(value, k)
+				continue
+			}
+			value[k] = dropNulls(field)
+		}
+	case []interface{}:
+		for i := range value {
+			value[i] = dropNulls(value[i])
+		}
+	}
+	return v
+}
@@ -0,0 +1,145 @@
+package cores
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/naser-989/xray-knife/v3/pkg"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/spf13/cobra"
+)
+
+// Config holds the configuration for the cores command
+type Config struct {
+	ConfigLink string
+	JSON       bool
+}
+
+// CoresCommand encapsulates the cores command functionality
+type CoresCommand struct {
+	config *Config
+}
+
+// NewCoresCommand creates a new instance of the cores command
+func NewCoresCommand() *cobra.Command {
+	cc := &CoresCommand{
+		config: &Config{},
+	}
+	return cc.createCommand()
+}
+
+// CoresCmd represents the cores command
+var CoresCmd = NewCoresCommand()
+
+// createCommand creates and configures the cobra command
+func (cc *CoresCommand) createCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cores",
+		Short: "Shows the schemes, transports and security layers each core supports",
+		Long: `Cores prints the capability matrix of the xray and sing-box cores, the auto core (-z auto)
+routes every config to a core that supports it. With --config, it tells which cores can run a config link.`,
+		RunE: cc.runCommand,
+	}
+
+	cc.addFlags(cmd)
+	return cmd
+}
+
+// addFlags adds command-line flags to the command
+func (cc *CoresCommand) addFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.StringVarP(&cc.config.ConfigLink, "config", "c", "", "Check which cores support a config link")
+	flags.BoolVar(&cc.config.JSON, "json", false, "Print the capabilities as JSON")
+}
+
+// runCommand executes the cores command logic
+func (cc *CoresCommand) runCommand(cmd *cobra.Command, args []string) error {
+	auto := pkg.NewAutomaticCore(false, false)
+	cores := []pkg.Core{
+		pkg.CoreFactory(pkg.XrayCoreType, false, false),
+		pkg.CoreFactory(pkg.SingboxCoreType, false, false),
+	}
+
+	if cc.config.ConfigLink != "" {
+		return checkLink(os.Stdout, auto, cores, cc.config.ConfigLink)
+	}
+
+	capabilities := make([]protocol.Capabilities, 0, len(cores))
+	for _, core := range cores {
+		capabilities = append(capabilities, core.Capabilities())
+	}
+	if cc.config.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(capabilities)
+	}
+	return writeMatrix(os.Stdout, capabilities, auto.Capabilities())
+}
+
+// writeMatrix writes a table with a row per scheme, transport and security layer, and a column per core
+func writeMatrix(w io.Writer, capabilities []protocol.Capabilities, all protocol.Capabilities) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	header := []string{""}
+	for _, c := range capabilities {
+		header = append(header, strings.ToUpper(c.Core))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	sections := []struct {
+		name   string
+		values []string
+		of     func(c protocol.Capabilities) []string
+	}{
+		{"scheme", all.Schemes, func(c protocol.Capabilities) []string { return c.Schemes }},
+		{"transport", all.Transports, func(c protocol.Capabilities) []string { return c.Transports }},
+		{"security", all.Security, func(c protocol.Capabilities) []string { return c.Security }},
+	}
+	for _, section := range sections {
+		for _, value := range section.values {
+			row := []string{section.name + " " + value}
+			for _, c := range capabilities {
+				mark := "-"
+				for _, v := range section.of(c) {
+					if v == value {
+						mark = "yes"
+					}
+				}
+				row = append(row, mark)
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	}
+	return tw.Flush()
+}
+
+// checkLink reports which cores support a config link, and the one the auto core picks
+func checkLink(w io.Writer, auto *pkg.AutomaticCore, cores []pkg.Core, link string) error {
+	p, err := protocol.CreateProtocol(link)
+	if err == nil {
+		err = p.Parse()
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't parse the config: %w", err)
+	}
+
+	for _, core := range cores {
+		if err = core.Capabilities().Check(p); err != nil {
+			fmt.Fprintf(w, "%s: %v\n", core.Name(), err)
+			continue
+		}
+		fmt.Fprintf(w, "%s: supported\n", core.Name())
+	}
+
+	selected, err := auto.SelectCore(link)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "auto: %s\n", selected.Name())
+	return nil
+}
@@ -0,0 +1,138 @@
+package dedupe
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/naser-989/xray-knife/v3/cmd/subs"
+	"github.com/naser-989/xray-knife/v3/pkg"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/naser-989/xray-knife/v3/utils"
+	"github.com/naser-989/xray-knife/v3/utils/customlog"
+	"github.com/spf13/cobra"
+)
+
+// Config holds the configuration for the dedupe command
+type Config struct {
+	ConfigLinksFiles []string
+	SubscriptionURLs []string
+	ReadFromSTDIN    bool
+	OutputFile       string
+}
+
+// DedupeCommand encapsulates the dedupe command functionality
+type DedupeCommand struct {
+	config *Config
+}
+
+// source is a named list of config links
+type source struct {
+	name  string
+	links []string
+}
+
+// NewDedupeCommand creates a new instance of the dedupe command
+func NewDedupeCommand() *cobra.Command {
+	dc := &DedupeCommand{
+		config: &Config{},
+	}
+	return dc.createCommand()
+}
+
+// DedupeCmd represents the dedupe command
+var DedupeCmd = NewDedupeCommand()
+
+// createCommand creates and configures the cobra command
+func (dc *DedupeCommand) createCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dedupe",
+		Short: "Removes the duplicate configs of one or more sources",
+		Long: `Dedupe keeps the first link of every config and drops the others.
+Two links are the same config when they have the same protocol, address, port, credential,
+transport, path/host, security and SNI, whatever their remark, parameter order or vmess JSON key order.
+The sources are deduplicated together, in the order of the flags (files, then subscriptions, then STDIN).`,
+		RunE: dc.runCommand,
+	}
+
+	dc.addFlags(cmd)
+	return cmd
+}
+
+// addFlags adds command-line flags to the command
+func (dc *DedupeCommand) addFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.StringArrayVarP(&dc.config.ConfigLinksFiles, "file", "f", nil, "Read config links from a file (links, xray/sing-box JSON, Clash YAML or wg-quick config), can be repeated")
+	flags.StringArrayVarP(&dc.config.SubscriptionURLs, "url", "u", nil, "Read config links from a subscription url, can be repeated")
+	flags.BoolVarP(&dc.config.ReadFromSTDIN, "stdin", "i", false, "Read config links from STDIN")
+	flags.StringVarP(&dc.config.OutputFile, "out", "o", "unique.txt", "The output file where the unique configs will be placed")
+}
+
+// runCommand executes the dedupe command logic
+func (dc *DedupeCommand) runCommand(cmd *cobra.Command, args []string) error {
+	if len(dc.config.ConfigLinksFiles) == 0 && len(dc.config.SubscriptionURLs) == 0 && !dc.config.ReadFromSTDIN {
+		return cmd.Help()
+	}
+
+	sources, err := dc.readSources()
+	if err != nil {
+		return err
+	}
+
+	var links []string
+	total, duplicates := 0, 0
+	seen := make(map[string]bool)
+	for _, src := range sources {
+		kept, n := protocol.Dedupe(src.links, seen)
+		customlog.Printf(customlog.Processing, "%s: %d links, %d duplicates collapsed\n", src.name, len(kept)+n, n)
+		links = append(links, kept...)
+		total += len(kept) + n
+		duplicates += n
+	}
+	customlog.Printf(customlog.Finished, "%d unique configs out of %d links\n", total-duplicates, total)
+
+	if err = utils.WriteIntoFile(dc.config.OutputFile, []byte(strings.Join(links, "\n\n"))); err != nil {
+		return fmt.Errorf("failed to save the links: %w", err)
+	}
+	customlog.Printf(customlog.Success, "%d configs have been saved into %s\n", len(links), dc.config.OutputFile)
+	return nil
+}
+
+// readSources reads the links of every selected source
+func (dc *DedupeCommand) readSources() ([]source, error) {
+	var sources []source
+
+	for _, file := range dc.config.ConfigLinksFiles {
+		links, err := pkg.ReadConfigLinks(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		sources = append(sources, source{name: file, links: links})
+	}
+
+	for _, url := range dc.config.SubscriptionURLs {
+		sub := subs.Subscription{Url: url}
+		links, err := sub.FetchAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+		}
+		sources = append(sources, source{name: url, links: links})
+	}
+
+	if dc.config.ReadFromSTDIN {
+		var links []string
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			links = append(links, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		sources = append(sources, source{name: "STDIN", links: links})
+	}
+
+	return sources, nil
+}
@@ -0,0 +1,233 @@
+package lint
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/naser-989/xray-knife/v3/cmd/subs"
+	"github.com/naser-989/xray-knife/v3/pkg"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/naser-989/xray-knife/v3/utils"
+	"github.com/naser-989/xray-knife/v3/utils/customlog"
+	"github.com/spf13/cobra"
+)
+
+// Config holds the configuration for the lint command
+type Config struct {
+	ConfigLink      string
+	ConfigLinksFile string
+	SubscriptionURL string
+	ReadFromSTDIN   bool
+	CoreType        string
+	Strict          bool
+	OutputFile      string
+}
+
+// LintCommand encapsulates the lint command functionality
+type LintCommand struct {
+	config *Config
+	core   pkg.Core
+}
+
+// Finding is the result of linting a single config link
+type Finding struct {
+	Link        string
+	Protocol    protocol.Protocol // nil when the link couldn't be parsed
+	Diagnostics protocol.Diagnostics
+}
+
+// NewLintCommand creates a new instance of the lint command
+func NewLintCommand() *cobra.Command {
+	lc := &LintCommand{
+		config: &Config{},
+	}
+	return lc.createCommand()
+}
+
+// LintCmd represents the lint command
+var LintCmd = NewLintCommand()
+
+// createCommand creates and configures the cobra command
+func (lc *LintCommand) createCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Checks config links for mistakes without testing them",
+		Long: `Lint parses every config link and reports the errors (the core will refuse the config)
+and warnings (the config probably won't work as intended) of each one, with the link parameter at fault.
+It exits with an error when any link has errors (or warnings with --strict).`,
+		RunE: lc.runCommand,
+	}
+
+	lc.addFlags(cmd)
+	return cmd
+}
+
+// addFlags adds command-line flags to the command
+func (lc *LintCommand) addFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.StringVarP(&lc.config.ConfigLink, "config", "c", "", "The config link")
+	flags.StringVarP(&lc.config.ConfigLinksFile, "file", "f", "", "Read config links from a file (links, xray/sing-box JSON, Clash YAML or wg-quick config)")
+	flags.StringVarP(&lc.config.SubscriptionURL, "url", "u", "", "Read config links from a subscription url")
+	flags.BoolVarP(&lc.config.ReadFromSTDIN, "stdin", "i", false, "Read config links from STDIN")
+	flags.StringVarP(&lc.config.CoreType, "core", "z", "auto", "Core type whose rules are checked (auto, singbox, xray)")
+	flags.BoolVarP(&lc.config.Strict, "strict", "s", false, "Treat warnings as errors")
+	flags.StringVarP(&lc.config.OutputFile, "out", "o", "", "Save the links that passed into a file")
+}
+
+// runCommand executes the lint command logic
+func (lc *LintCommand) runCommand(cmd *cobra.Command, args []string) error {
+	if lc.config.ConfigLink == "" && lc.config.ConfigLinksFile == "" && lc.config.SubscriptionURL == "" && !lc.config.ReadFromSTDIN {
+		return cmd.Help()
+	}
+
+	links, err := lc.readLinks()
+	if err != nil {
+		return fmt.Errorf("failed to read config links: %w", err)
+	}
+
+	coreType, err := pkg.ParseCoreType(lc.config.CoreType)
+	if err != nil {
+		return err
+	}
+	// The automatic core checks each link against the core net http would test it with
+	lc.core = pkg.CoreFactory(coreType, false, false)
+
+	var passed []string
+	withErrors, withWarnings := 0, 0
+	for i, link := range links {
+		finding := lc.Lint(link)
+		printFinding(i+1, finding)
+
+		switch {
+		case finding.Diagnostics.HasErrors():
+			withErrors++
+		case len(finding.Diagnostics) != 0:
+			withWarnings++
+			if !lc.config.Strict {
+				passed = append(passed, link)
+			}
+		default:
+			passed = append(passed, link)
+		}
+	}
+
+	fmt.Println()
+	customlog.Printf(customlog.Finished, "Checked %d links: %d with errors, %d with warnings only, %d clean\n",
+		len(links), withErrors, withWarnings, len(links)-withErrors-withWarnings)
+
+	if lc.config.OutputFile != "" {
+		if err = utils.WriteIntoFile(lc.config.OutputFile, []byte(strings.Join(passed, "\n")+"\n")); err != nil {
+			return fmt.Errorf("failed to save the links: %w", err)
+		}
+		customlog.Printf(customlog.Success, "%d links that passed have been saved into %s\n", len(passed), lc.config.OutputFile)
+	}
+
+	// The findings were already printed, the usage would only bury them
+	cmd.SilenceUsage = true
+	if withErrors != 0 || (lc.config.Strict && withWarnings != 0) {
+		return errors.New("some config links didn't pass the lint")
+	}
+	return nil
+}
+
+// Lint parses a link with the selected core and validates it.
+// A link that can't be parsed is reported as a single error.
+func (lc *LintCommand) Lint(link string) Finding {
+	finding := Finding{Link: link}
+
+	p, err := lc.parseLink(link)
+	if err != nil {
+		finding.Diagnostics.Errorf("", "couldn't parse the config: %v", err)
+		return finding
+	}
+
+	finding.Protocol = p
+	finding.Diagnostics = p.Validate()
+
+	var unsupported *protocol.UnsupportedError
+	if err = lc.core.Capabilities().Check(p); errors.As(err, &unsupported) {
+		finding.Diagnostics.Errorf(unsupported.Field, "%v", err)
+	}
+	return finding
+}
+
+func (lc *LintCommand) parseLink(link string) (protocol.Protocol, error) {
+	p, err := lc.core.CreateProtocol(link)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.Parse(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// printFinding prints the diagnostics of a link, clean links get a single line
+func printFinding(n int, f Finding) {
+	name := protocol.RedactLink(f.Link)
+	if f.Protocol != nil {
+		g := f.Protocol.ConvertToGeneralConfig()
+		server := g.Address
+		if g.Port != "" {
+			server = protocol.JoinHostPort(g.Address, g.Port)
+		}
+		name = fmt.Sprintf("%s (%s %s)", g.Remark, g.Protocol, server)
+	}
+
+	if len(f.Diagnostics) == 0 {
+		fmt.Printf("%s %s %s\n", color.CyanString("[%d]", n), name, color.GreenString("ok"))
+		return
+	}
+
+	fmt.Printf("%s %s\n", color.CyanString("[%d]", n), name)
+	for _, d := range f.Diagnostics {
+		severity := color.YellowString("%-7s", d.Severity)
+		if d.Severity == protocol.SeverityError {
+			severity = color.RedString("%-7s", d.Severity)
+		}
+		if d.Field == "" {
+			fmt.Printf("    %s %s\n", severity, d.Message)
+		} else {
+			fmt.Printf("    %s %s: %s\n", severity, d.Field, d.Message)
+		}
+	}
+}
+
+// readLinks collects the config links from the selected input
+func (lc *LintCommand) readLinks() ([]string, error) {
+	var links []string
+	var err error
+
+	switch {
+	case lc.config.ConfigLink != "":
+		links = []string{lc.config.ConfigLink}
+	case lc.config.ConfigLinksFile != "":
+		links, err = pkg.ReadConfigLinks(lc.config.ConfigLinksFile)
+	case lc.config.SubscriptionURL != "":
+		sub := subs.Subscription{Url: lc.config.SubscriptionURL}
+		links, err = sub.FetchAll()
+	default:
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			links = append(links, scanner.Text())
+		}
+		err = scanner.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, link := range links {
+		if link = strings.TrimSpace(link); link != "" {
+			result = append(result, link)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/naser-989/xray-knife/v3/pkg"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+)
+
+func newTestCommand() *LintCommand {
+	return &LintCommand{
+		config: &Config{CoreType: "auto"},
+		core:   pkg.CoreFactory(pkg.AutoCoreType, false, false),
+	}
+}
+
+func TestLintCommand_Lint(t *testing.T) {
+	tests := []struct {
+		link     string
+		severity protocol.Severity // Empty for a clean link
+		field    string
+	}{
+		{"vless://0090bbba-1118-46ca-87a1-52599cee74ab@1.2.3.4:443?type=tcp&security=reality&flow=xtls-rprx-vision&sni=www.speedtest.net&pbk=7xhH4b_VkliBxGulljcyPOH-bYUA2dl-XAdZAsfhk2U&sid=6ba85179e30d4fc2&fp=chrome#reality", "", ""},
+		{"vless://0090bbba-1118-46ca-87a1-52599cee74ab@1.2.3.4:443?type=tcp&security=reality&sni=www.speedtest.net&sid=6ba85179e30d4fc2#no-pbk", protocol.SeverityError, "pbk"},
+		{"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=ws&security=tls&flow=xtls-rprx-vision&sni=example.com#vision-ws", protocol.SeverityError, "flow"},
+		{"trojan://secret@example.com:443?security=tls&type=grpc&sni=example.com#grpc", protocol.SeverityWarning, "serviceName"},
+		{"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=ws&security=tls&fp=netscape#fp", protocol.SeverityError, "fp"},
+		{"vless://not-a-uuid-but-much-longer-than-thirty-bytes@example.com:443?type=ws#uuid", protocol.SeverityError, "id"},
+		{"ss://MjAyMi1ibGFrZTMtYWVzLTEyOC1nY206c2VjcmV0@example.com:443#short-key", protocol.SeverityError, "password"},
+		{"hysteria2://pw@example.com:443?obfs=salamander#no-obfs-password", protocol.SeverityError, "obfs-password"},
+		{"unknown://example.com:443", protocol.SeverityError, ""},
+	}
+
+	lc := newTestCommand()
+	for _, test := range tests {
+		finding := lc.Lint(test.link)
+		if test.severity == "" {
+			if len(finding.Diagnostics) != 0 {
+				t.Errorf("%s: unexpected diagnostics %v", test.link, finding.Diagnostics)
+			}
+			continue
+		}
+
+		found := false
+		for _, d := range finding.Diagnostics {
+			if d.Severity == test.severity && d.Field == test.field {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected a %s on %q, got %v", test.link, test.severity, test.field, finding.Diagnostics)
+		}
+	}
+}
+
+func TestLintCommand_LintCapabilities(t *testing.T) {
+	lc := &LintCommand{
+		config: &Config{CoreType: "singbox"},
+		core:   pkg.CoreFactory(pkg.SingboxCoreType, false, false),
+	}
+
+	finding := lc.Lint("vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=xhttp&security=tls&sni=example.com#xhttp")
+	found := false
+	for _, d := range finding.Diagnostics {
+		if d.Severity == protocol.SeverityError && d.Field == "type" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected an error on the xhttp transport, got %v", finding.Diagnostics)
+	}
+}
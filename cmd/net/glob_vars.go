@@ -2,5 +2,5 @@ package net
 
 var (
 	configLink string
+	coreType   string
 )
-
@@ -10,6 +10,8 @@ import (
 	"github.com/fatih/color"
 	"github.com/gocarina/gocsv"
 	"github.com/naser-989/xray-knife/v3/pkg"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/naser-989/xray-knife/v3/pkg/xray"
 	"github.com/naser-989/xray-knife/v3/utils"
 	"github.com/naser-989/xray-knife/v3/utils/customlog"
 	"github.com/spf13/cobra"
@@ -23,12 +25,17 @@ type Config struct {
 	OutputType          string
 	ThreadCount         uint16
 	CoreType            string
+	CoreRoutes          []string
+	Fragment            string
+	Noises              []string
 	DestURL             string
 	HTTPMethod          string
 	ShowBody            bool
 	InsecureTLS         bool
 	Verbose             bool
 	SortedByRealDelay   bool
+	Dedupe              bool
+	Rename              string
 	Speedtest           bool
 	GetIPInfo           bool
 	SpeedtestAmount     uint32
@@ -68,6 +75,10 @@ type TestManager struct {
 	processor   *ResultProcessor
 	threadCount uint16
 	verbose     bool
+
+	// Configs that couldn't be parsed, by reason
+	failures   protocol.ParseFailures
+	failuresMu sync.Mutex
 }
 
 // NewTestManager creates a new TestManager instance
@@ -77,6 +88,7 @@ func NewTestManager(examiner *pkg.Examiner, processor *ResultProcessor, threadCo
 		processor:   processor,
 		threadCount: threadCount,
 		verbose:     verbose,
+		failures:    make(protocol.ParseFailures),
 	}
 }
 
@@ -106,8 +118,11 @@ func (tm *TestManager) testSingleConfig(link string, index int, results *ConfigR
 
 	res, err := tm.examiner.ExamineConfig(link)
 	if err != nil {
+		tm.failuresMu.Lock()
+		tm.failures.Add(err)
+		tm.failuresMu.Unlock()
 		if tm.verbose {
-			customlog.Printf(customlog.Failure, "Error: %s - broken config: %s\n", err.Error(), link)
+			customlog.Printf(customlog.Failure, "Error: %s - broken config: %s\n", err.Error(), protocol.RedactLink(link))
 		}
 		return
 	}
@@ -137,6 +152,12 @@ func (rp *ResultProcessor) SaveResults(results ConfigResults) error {
 		sort.Sort(results)
 	}
 
+	if rp.config.Rename != "" {
+		if err := rp.renameResults(results); err != nil {
+			return err
+		}
+	}
+
 	switch rp.config.OutputType {
 	case "txt":
 		return rp.saveTxtResults(results)
@@ -147,6 +168,27 @@ func (rp *ResultProcessor) SaveResults(results ConfigResults) error {
 	}
 }
 
+// renameResults rewrites the remarks of the configs to be saved with the rename template
+func (rp *ResultProcessor) renameResults(results ConfigResults) error {
+	renamer, err := pkg.NewRenamer(rp.config.Rename)
+	if err != nil {
+		return err
+	}
+
+	index := 0
+	for _, v := range results {
+		// The txt output only has the working configs
+		if rp.config.OutputType == "txt" && v.Status != "passed" {
+			continue
+		}
+		index++
+		if err = renamer.Rename(v, index); err != nil {
+			customlog.Printf(customlog.Failure, "Couldn't rename %s: %v\n", protocol.RedactLink(v.ConfigLink), err)
+		}
+	}
+	return nil
+}
+
 // saveTxtResults saves results in text format
 func (rp *ResultProcessor) saveTxtResults(results ConfigResults) error {
 	for _, v := range results {
@@ -167,7 +209,18 @@ func (rp *ResultProcessor) saveTxtResults(results ConfigResults) error {
 
 // saveCSVResults saves results in CSV format
 func (rp *ResultProcessor) saveCSVResults(results ConfigResults) error {
-	out, err := gocsv.MarshalString(&results)
+	rows := results
+	if protocol.Redacting() {
+		// The report is meant to be shared, the links keep working only in the txt output
+		rows = make(ConfigResults, len(results))
+		for i, v := range results {
+			row := *v
+			row.ConfigLink = protocol.RedactLink(v.ConfigLink)
+			rows[i] = &row
+		}
+	}
+
+	out, err := gocsv.MarshalString(&rows)
 	if err != nil {
 		return fmt.Errorf("failed to marshal CSV: %v", err)
 	}
@@ -189,9 +242,9 @@ func (rp *ResultProcessor) saveCSVResults(results ConfigResults) error {
 
 // validateConfig validates the configuration options
 func validateConfig(cfg *Config) error {
-	validCores := map[string]bool{"auto": true, "xray": true, "singbox": true}
-	if !validCores[cfg.CoreType] {
-		return fmt.Errorf("invalid core type. Available cores: (auto, xray, singbox)")
+	coreType, err := pkg.ParseCoreType(cfg.CoreType)
+	if err != nil {
+		return err
 	}
 
 	validOutputTypes := map[string]bool{"csv": true, "txt": true}
@@ -199,6 +252,20 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("bad output format. Allowed formats: txt, csv")
 	}
 
+	if cfg.Rename != "" {
+		if _, err := pkg.NewRenamer(cfg.Rename); err != nil {
+			return err
+		}
+	}
+
+	xrayOpts, err := xray.ParseAntiDPI(cfg.Fragment, cfg.Noises)
+	if err != nil {
+		return err
+	}
+	if len(xrayOpts) > 0 && coreType == pkg.SingboxCoreType {
+		return fmt.Errorf("--fragment and --noise need the xray or auto core, add -z xray or -z auto")
+	}
+
 	if cfg.OutputType == "csv" {
 		base := strings.TrimSuffix(cfg.OutputFile, filepath.Ext(cfg.OutputFile))
 		cfg.OutputFile = base + ".csv"
@@ -221,6 +288,11 @@ func NewHTTPCommand() *cobra.Command {
 				return err
 			}
 
+			xrayOpts, err := xray.ParseAntiDPI(config.Fragment, config.Noises)
+			if err != nil {
+				return err
+			}
+
 			// Instantiate a Examiner
 			examiner, err := pkg.NewExaminer(pkg.Options{
 				Core:                   config.CoreType,
@@ -233,10 +305,14 @@ func NewHTTPCommand() *cobra.Command {
 				TestEndpoint:           config.DestURL,
 				TestEndpointHttpMethod: config.HTTPMethod,
 				SpeedtestKbAmount:      config.SpeedtestAmount,
+				XrayOptions:            xrayOpts,
 			})
 			if err != nil {
 				return fmt.Errorf("failed to create examiner: %v", err)
 			}
+			if err = pkg.SetCoreRoutes(examiner.Core, config.CoreRoutes); err != nil {
+				return err
+			}
 
 			// Instantiate a Result Processor
 			processor := NewResultProcessor(config)
@@ -256,7 +332,15 @@ func NewHTTPCommand() *cobra.Command {
 
 // handleMultipleConfigs handles testing multiple configurations
 func handleMultipleConfigs(examiner *pkg.Examiner, config *Config, processor *ResultProcessor) error {
-	links := utils.ParseFileByNewline(config.ConfigLinksFile)
+	links, err := pkg.ReadConfigLinks(config.ConfigLinksFile)
+	if err != nil {
+		return fmt.Errorf("failed to read configs: %v", err)
+	}
+	if config.Dedupe {
+		var duplicates int
+		links, duplicates = protocol.Dedupe(links, make(map[string]bool))
+		customlog.Printf(customlog.Processing, "%s: %d duplicates collapsed\n", config.ConfigLinksFile, duplicates)
+	}
 	printConfiguration(config, len(links))
 
 	if config.Speedtest && config.OutputType != "csv" {
@@ -266,6 +350,9 @@ func handleMultipleConfigs(examiner *pkg.Examiner, config *Config, processor *Re
 
 	testManager := NewTestManager(examiner, processor, config.ThreadCount, true)
 	results := testManager.TestConfigs(links)
+	if n := testManager.failures.Total(); n > 0 {
+		customlog.Printf(customlog.Failure, "%d configs couldn't be parsed: %s\n", n, testManager.failures)
+	}
 
 	return processor.SaveResults(results)
 }
@@ -283,7 +370,7 @@ func handleSingleConfig(examiner *pkg.Examiner, config *Config) error {
 		return nil
 	}
 
-	customlog.Printf(customlog.Success, "Real Delay: %dms\n", res.Delay)
+	customlog.Printf(customlog.Success, "Real Delay: %dms (%s core)\n", res.Delay, res.Core)
 	if config.Speedtest {
 		customlog.Printf(customlog.Success, "Downloaded %dKB - Speed: %f mbps\n",
 			config.SpeedtestAmount, res.DownloadSpeed)
@@ -311,9 +398,12 @@ func printConfiguration(config *Config, totalConfigs int) {
 func addFlags(cmd *cobra.Command, config *Config) {
 	flags := cmd.Flags()
 	flags.StringVarP(&config.ConfigLink, "config", "c", "", "The xray config link")
-	flags.StringVarP(&config.ConfigLinksFile, "file", "f", "", "Read config links from a file")
+	flags.StringVarP(&config.ConfigLinksFile, "file", "f", "", "Read config links from a file (links, xray/sing-box JSON, Clash YAML or wg-quick config)")
 	flags.Uint16VarP(&config.ThreadCount, "thread", "t", 5, "Number of threads to be used for checking links from file")
 	flags.StringVarP(&config.CoreType, "core", "z", "auto", "Core type (auto, singbox, xray)")
+	flags.StringArrayVar(&config.CoreRoutes, "route", nil, "Route the links of a scheme to a core with the auto core (e.g. socks=singbox), can be repeated")
+	flags.StringVar(&config.Fragment, "fragment", "", "Fragment the connections of the xray core: packets,length,interval (e.g. tlshello,100-200,10-20)")
+	flags.StringArrayVar(&config.Noises, "noise", nil, "Send a noise packet before the UDP connections of the xray core: type,packet,delay (e.g. rand,10-20,10-16), can be repeated")
 	flags.StringVarP(&config.DestURL, "url", "u", "https://cloudflare.com/cdn-cgi/trace", "The url to test config")
 	flags.StringVarP(&config.HTTPMethod, "method", "m", "GET", "Http method")
 	flags.BoolVarP(&config.ShowBody, "body", "b", false, "Show response body")
@@ -326,4 +416,7 @@ func addFlags(cmd *cobra.Command, config *Config) {
 	flags.StringVarP(&config.OutputType, "type", "x", "txt", "Output type (csv, txt)")
 	flags.StringVarP(&config.OutputFile, "out", "o", "valid.txt", "Output file for valid config links")
 	flags.BoolVarP(&config.SortedByRealDelay, "sort", "s", true, "Sort config links by their delay (fast to slow)")
+	flags.StringVar(&config.Rename, "rename", "", "Rename the saved configs with a template, e.g. '{{.Country}} {{.Delay}}ms {{.Protocol}}-{{.Network}} #{{.Index}}'\n"+
+		"(fields: Index, Remark, Protocol, Network, Security, Address, Port, Status, Core, Delay, IP, Country, Download, Upload)")
+	flags.BoolVar(&config.Dedupe, "dedupe", false, "Remove the duplicate configs (same server, credential and transport) before testing them")
 }
@@ -10,13 +10,13 @@ import (
 	"strings"
 
 	"github.com/naser-989/xray-knife/v3/network"
-	"github.com/naser-989/xray-knife/v3/pkg/xray"
 	"github.com/spf13/cobra"
 )
 
 // ICMPConfig holds the configuration for the ICMP command
 type ICMPConfig struct {
 	ConfigLink string
+	CoreType   string
 	TestCount  uint16
 	DestIP     net.IP
 }
@@ -24,14 +24,13 @@ type ICMPConfig struct {
 // ICMPCommand encapsulates the ICMP command functionality
 type ICMPCommand struct {
 	config *ICMPConfig
-	xray   pkg.Core
+	core   pkg.Core
 }
 
 // NewICMPCommand creates a new instance of the ICMP command
 func NewICMPCommand() *cobra.Command {
 	ic := &ICMPCommand{
 		config: &ICMPConfig{},
-		xray:   xray.NewXrayService(false, false),
 	}
 	return ic.createCommand()
 }
@@ -53,11 +52,18 @@ func (ic *ICMPCommand) createCommand() *cobra.Command {
 func (ic *ICMPCommand) addFlags(cmd *cobra.Command) {
 	flags := cmd.Flags()
 	flags.StringVarP(&ic.config.ConfigLink, "config", "c", "", "The xray config link")
+	flags.StringVarP(&ic.config.CoreType, "core", "z", "auto", "Core type (auto, singbox, xray)")
 	flags.Uint16VarP(&ic.config.TestCount, "count", "t", 4, "Count of tests")
 }
 
 // runCommand executes the ICMP command logic
 func (ic *ICMPCommand) runCommand(cmd *cobra.Command, args []string) error {
+	coreType, err := pkg.ParseCoreType(ic.config.CoreType)
+	if err != nil {
+		return err
+	}
+	ic.core = pkg.CoreFactory(coreType, false, false)
+
 	protocol, err := ic.parseConfig()
 	if err != nil {
 		return fmt.Errorf("failed to parse configuration: %w", err)
@@ -84,7 +90,7 @@ func (ic *ICMPCommand) parseConfig() (protocol.Protocol, error) {
 		text, _ := reader.ReadString('\n')
 		ic.config.ConfigLink = strings.TrimSpace(text)
 	}
-	protocol, err := ic.xray.CreateProtocol(ic.config.ConfigLink)
+	protocol, err := ic.core.CreateProtocol(ic.config.ConfigLink)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create protocol: %w", err)
 	}
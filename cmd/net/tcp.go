@@ -1,7 +1,7 @@
 package net
 
 import (
-	"github.com/naser-989/xray-knife/v3/pkg/xray"
+	"github.com/naser-989/xray-knife/v3/pkg"
 	"net"
 	"os"
 	"time"
@@ -17,11 +17,19 @@ var TcpCmd = &cobra.Command{
 	Short: "Examine TCP Connection delay to config's host",
 	Long:  ``,
 	Run: func(cmd *cobra.Command, args []string) {
-		x := xray.NewXrayService(false, false)
+		ct, err := pkg.ParseCoreType(coreType)
+		if err != nil {
+			customlog.Printf(customlog.Failure, "%v\n", err)
+			os.Exit(1)
+		}
+		core := pkg.CoreFactory(ct, false, false)
 
-		parsed, err := x.CreateProtocol(configLink)
+		parsed, err := core.CreateProtocol(configLink)
+		if err == nil {
+			err = parsed.Parse()
+		}
 		if err != nil {
-			customlog.Printf(customlog.Failure, "Couldn't parse the config!\n")
+			customlog.Printf(customlog.Failure, "Couldn't parse the config: %v\n", err)
 			os.Exit(1)
 		}
 		generalDetails := parsed.ConvertToGeneralConfig()
@@ -44,4 +52,5 @@ var TcpCmd = &cobra.Command{
 
 func init() {
 	TcpCmd.Flags().StringVarP(&configLink, "config", "c", "", "The xray config link")
+	TcpCmd.Flags().StringVarP(&coreType, "core", "z", "auto", "Core type (auto, singbox, xray)")
 }
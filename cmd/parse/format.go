@@ -0,0 +1,121 @@
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gocarina/gocsv"
+	"github.com/naser-989/xray-knife/v3/pkg"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+)
+
+// Output formats of the parse command, text being the colored details
+var formats = []string{"text", "json", "csv", "table"}
+
+// recordRow is the flat CSV form of a protocol.Record
+type recordRow struct {
+	Protocol         string `csv:"protocol"`
+	Remark           string `csv:"remark"`
+	Address          string `csv:"address"`
+	Port             int    `csv:"port"`
+	Security         string `csv:"security"`
+	Network          string `csv:"network"`
+	HeaderType       string `csv:"header_type"`
+	Host             string `csv:"host"`
+	Path             string `csv:"path"`
+	ServiceName      string `csv:"service_name"`
+	SNI              string `csv:"sni"`
+	ALPN             string `csv:"alpn"` // Comma separated
+	Fingerprint      string `csv:"fingerprint"`
+	Insecure         bool   `csv:"insecure"`
+	PublicKey        string `csv:"public_key"`
+	ShortID          string `csv:"short_id"`
+	SpiderX          string `csv:"spider_x"`
+	Flow             string `csv:"flow"`
+	Link             string `csv:"link"`
+	HysteriaProtocol string `csv:"hysteria_protocol"`
+}
+
+func newRecordRow(r protocol.Record) recordRow {
+	row := recordRow{
+		Protocol:         r.Protocol,
+		Remark:           r.Remark,
+		Address:          r.Address,
+		Port:             r.Port,
+		Security:         string(r.Security),
+		Flow:             r.Flow,
+		Link:             r.Link,
+		HysteriaProtocol: r.HysteriaProtocol,
+	}
+	if t := r.Transport; t != nil {
+		row.Network, row.HeaderType, row.Host, row.Path, row.ServiceName = t.Network, t.HeaderType, t.Host, t.Path, t.ServiceName
+	}
+	if t := r.TLS; t != nil {
+		row.SNI, row.ALPN, row.Fingerprint, row.Insecure = t.SNI, strings.Join(t.ALPN, ","), t.Fingerprint, t.Insecure
+	}
+	if re := r.Reality; re != nil {
+		row.PublicKey, row.ShortID, row.SpiderX = re.PublicKey, re.ShortID, re.SpiderX
+	}
+	return row
+}
+
+// parseRecords parses every link into its record, the links that fail are reported on stderr and skipped
+func parseRecords(core pkg.Core, links []string) []protocol.Record {
+	records := []protocol.Record{}
+	failures := make(protocol.ParseFailures)
+	defer printFailures(failures)
+	for _, link := range links {
+		p, err := core.CreateProtocol(link)
+		if err == nil {
+			err = p.Parse()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't parse the config %s: %v\n", protocol.RedactLink(link), err)
+			failures.Add(err)
+			continue
+		}
+		records = append(records, p.ConvertToGeneralConfig().Record().Redacted())
+	}
+	return records
+}
+
+// printFailures reports the number of links that couldn't be parsed by reason on stderr
+func printFailures(failures protocol.ParseFailures) {
+	if n := failures.Total(); n > 0 {
+		fmt.Fprintf(os.Stderr, "%d configs couldn't be parsed: %s\n", n, failures)
+	}
+}
+
+// writeRecords writes the records in a json, csv or table format
+func writeRecords(w io.Writer, records []protocol.Record, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		// Links are full of &, they're kept readable
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(records)
+	case "csv":
+		rows := make([]recordRow, 0, len(records))
+		for _, r := range records {
+			rows = append(rows, newRecordRow(r))
+		}
+		return gocsv.Marshal(&rows, w)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "#\tPROTOCOL\tREMARK\tADDRESS\tPORT\tSECURITY\tNETWORK\tSNI")
+		for i, r := range records {
+			row := newRecordRow(r)
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", i+1, row.Protocol, row.Remark, row.Address,
+				strconv.Itoa(row.Port), row.Security, row.Network, row.SNI)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown format %q (allowed: %s)", format, strings.Join(formats, ", "))
+	}
+}
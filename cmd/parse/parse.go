@@ -6,13 +6,11 @@ import (
 	"github.com/naser-989/xray-knife/v3/pkg"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
 	"log"
-	"net/url"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
-	"github.com/naser-989/xray-knife/v3/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -20,6 +18,8 @@ var (
 	readFromSTDIN   bool
 	configLink      string
 	configLinksFile string
+	outputFormat    string
+	coreType        string
 )
 
 // ParseCmd represents the parse command
@@ -33,41 +33,59 @@ var ParseCmd = &cobra.Command{
 			return
 		}
 
-		xrayCore := pkg.CoreFactory(pkg.XrayCoreType, false, false)
-		singboxCore := pkg.CoreFactory(pkg.SingboxCoreType, false, false)
-		SelectedCore := map[string]pkg.Core{
-			protocol.VmessIdentifier:       xrayCore,
-			protocol.VlessIdentifier:       xrayCore,
-			protocol.ShadowsocksIdentifier: xrayCore,
-			protocol.TrojanIdentifier:      xrayCore,
-			protocol.SocksIdentifier:       singboxCore,
-			protocol.WireguardIdentifier:   singboxCore,
-			protocol.Hysteria2Identifier:   singboxCore,
-			"hy2":                          singboxCore,
+		ct, err := pkg.ParseCoreType(coreType)
+		if err != nil {
+			log.Fatalln(err)
 		}
-
-		var core pkg.Core
+		// The details don't depend on the core, it only rejects the protocols it doesn't support
+		core := pkg.CoreFactory(ct, false, false)
 
 		if readFromSTDIN {
 			reader := bufio.NewReader(os.Stdin)
-			fmt.Println("Enter your config link:")
+			fmt.Fprintln(os.Stderr, "Enter your config link:")
 			text, _ := reader.ReadString('\n')
 			configLink = text
+		}
+
+		if outputFormat != "text" {
+			links := []string{strings.TrimSpace(configLink)}
+			if configLinksFile != "" {
+				var err error
+				if links, err = pkg.ReadConfigLinks(configLinksFile); err != nil {
+					log.Fatalf("Couldn't read the configs: %v\n", err)
+				}
+			}
+			if err := writeRecords(os.Stdout, parseRecords(core, links), outputFormat); err != nil {
+				log.Fatalln(err)
+			}
+			return
+		}
 
-		} else if configLinksFile != "" {
-			links := utils.ParseFileByNewline(configLinksFile)
+		if configLinksFile != "" {
+			links, err := pkg.ReadConfigLinks(configLinksFile)
+			if err != nil {
+				log.Fatalf("Couldn't read the configs: %v\n", err)
+			}
 			//fmt.Println(links)
 			d := color.New(color.FgCyan, color.Bold)
+			failures := make(protocol.ParseFailures)
 			for i, link := range links {
 				d.Printf("Config Number: %d\n", i+1)
-				protocol, err := core.CreateProtocol(link)
+				p, err := core.CreateProtocol(link)
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "%v", err)
-					os.Exit(1)
+					fmt.Fprintf(os.Stderr, "Couldn't parse the config: %v\n\n", err)
+					failures.Add(err)
+					continue
 				}
-				fmt.Println(protocol.DetailsStr() + "\n")
+				if err = p.Parse(); err != nil {
+					fmt.Fprintf(os.Stderr, "%v\n\n", err)
+					failures.Add(err)
+					continue
+				}
+				fmt.Println(p.DetailsStr() + "\n")
 				time.Sleep(time.Duration(25) * time.Millisecond)
 			}
+			printFailures(failures)
 			return
 
 		}
@@ -76,21 +94,10 @@ var ParseCmd = &cobra.Command{
 			// Remove any spaces
 			configLink = strings.TrimSpace(configLink)
 
-			uri, err := url.Parse(configLink)
-			if err != nil {
-				log.Fatalf("Couldn't parse the config: %v\n", err)
-			}
-
-			coreAuto, ok := SelectedCore[uri.Scheme]
-			if !ok {
-				log.Fatalln("Couldn't parse the config: invalid protocol")
-			}
-
 			fmt.Printf("\n")
-			p, err := coreAuto.CreateProtocol(configLink)
+			p, err := core.CreateProtocol(configLink)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "%v", err)
-				os.Exit(1)
+				log.Fatalf("Couldn't parse the config: %v\n", err)
 			}
 			err = p.Parse()
 			if err != nil {
@@ -106,5 +113,7 @@ var ParseCmd = &cobra.Command{
 func init() {
 	ParseCmd.Flags().BoolVarP(&readFromSTDIN, "stdin", "i", false, "Read config link from the console")
 	ParseCmd.Flags().StringVarP(&configLink, "config", "c", "", "The config link")
-	ParseCmd.Flags().StringVarP(&configLinksFile, "file", "f", "", "Read config links from a file")
+	ParseCmd.Flags().StringVarP(&configLinksFile, "file", "f", "", "Read config links from a file (links, xray/sing-box JSON, Clash YAML or wg-quick config)")
+	ParseCmd.Flags().StringVarP(&coreType, "core", "z", "auto", "Core type (auto, singbox, xray)")
+	ParseCmd.Flags().StringVar(&outputFormat, "format", "text", "Output format (text, json, csv, table), json and csv have one record per link")
 }
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/naser-989/xray-knife/v3/pkg"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
-	"github.com/naser-989/xray-knife/v3/pkg/singbox"
 	"github.com/naser-989/xray-knife/v3/pkg/xray"
 	"log"
 	"math/rand"
@@ -24,6 +23,9 @@ import (
 
 var (
 	CoreType            string
+	coreRoutes          []string
+	fragment            string
+	noises              []string
 	interval            uint32
 	configLinksFile     string
 	readConfigFromSTDIN bool
@@ -48,29 +50,29 @@ var ProxyCmd = &cobra.Command{
 		}
 
 		var core pkg.Core
-		var inbound protocol.Protocol
-
-		switch CoreType {
-		case "xray":
-			core = pkg.CoreFactory(pkg.XrayCoreType, insecureTLS, verbose)
+		inbound := &protocol.Socks{
+			Remark:  "Listener",
+			Address: listenAddr,
+			Port:    listenPort,
+		}
 
-			inbound = &xray.Socks{
-				Remark:  "Listener",
-				Address: listenAddr,
-				Port:    listenPort,
-			}
-			break
-		case "singbox":
-			core = pkg.CoreFactory(pkg.SingboxCoreType, insecureTLS, verbose)
-
-			inbound = &singbox.Socks{
-				Remark:  "Listener",
-				Address: listenAddr,
-				Port:    listenPort,
-			}
-			break
-		default:
-			log.Fatalln("Allowed core types: (xray, singbox)")
+		coreType, err := pkg.ParseCoreType(CoreType)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		xrayOpts, err := xray.ParseAntiDPI(fragment, noises)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if len(xrayOpts) > 0 && coreType == pkg.SingboxCoreType {
+			log.Fatalln("--fragment and --noise need the xray or auto core, add -z xray or -z auto")
+		}
+		core = pkg.CoreFactory(coreType, insecureTLS, verbose)
+		if err = pkg.SetCoreRoutes(core, coreRoutes); err != nil {
+			log.Fatalln(err)
+		}
+		if err = pkg.SetXrayOptions(core, xrayOpts...); err != nil {
+			log.Fatalln(err)
 		}
 
 		inErr := core.SetInbound(inbound)
@@ -84,7 +86,11 @@ var ProxyCmd = &cobra.Command{
 
 		if configLinksFile != "" {
 			// Get configs from file
-			links = utils.ParseFileByNewline(configLinksFile)
+			var err error
+			links, err = pkg.ReadConfigLinks(configLinksFile)
+			if err != nil {
+				log.Fatalf("Couldn't read the configs: %v", err)
+			}
 
 		} else if readConfigFromSTDIN {
 			// Get config from STDIN
@@ -123,8 +129,6 @@ var ProxyCmd = &cobra.Command{
 
 		var instance protocol.Instance = nil
 
-		var err error
-
 		// Create a channel to receive signals.
 		signalChannel := make(chan os.Signal, 1)
 
@@ -318,11 +322,14 @@ var ProxyCmd = &cobra.Command{
 
 func init() {
 	ProxyCmd.Flags().BoolVarP(&readConfigFromSTDIN, "stdin", "i", false, "Read config link from STDIN")
-	ProxyCmd.Flags().StringVarP(&configLinksFile, "file", "f", "", "Read config links from a file")
+	ProxyCmd.Flags().StringVarP(&configLinksFile, "file", "f", "", "Read config links from a file (links, xray/sing-box JSON, Clash YAML or wg-quick config)")
 	ProxyCmd.Flags().Uint32VarP(&interval, "interval", "t", 300, "Interval to change outbound connection in seconds")
 	ProxyCmd.Flags().Uint16VarP(&maximumAllowedDelay, "mdelay", "d", 3000, "Maximum allowed delay")
 
-	ProxyCmd.Flags().StringVarP(&CoreType, "core", "z", "singbox", "Core types: (xray, singbox)")
+	ProxyCmd.Flags().StringVarP(&CoreType, "core", "z", "singbox", "Core types: (auto, xray, singbox)")
+	ProxyCmd.Flags().StringArrayVar(&coreRoutes, "route", nil, "Route the links of a scheme to a core with the auto core (e.g. socks=singbox), can be repeated")
+	ProxyCmd.Flags().StringVar(&fragment, "fragment", "", "Fragment the connections of the xray core: packets,length,interval (e.g. tlshello,100-200,10-20), needs -z xray or auto")
+	ProxyCmd.Flags().StringArrayVar(&noises, "noise", nil, "Send a noise packet before the UDP connections of the xray core: type,packet,delay (e.g. rand,10-20,10-16), can be repeated, needs -z xray or auto")
 
 	ProxyCmd.Flags().StringVarP(&listenAddr, "addr", "a", "127.0.0.1", "Listen ip address")
 	ProxyCmd.Flags().StringVarP(&listenPort, "port", "p", "9999", "Listen port number")
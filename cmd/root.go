@@ -4,10 +4,15 @@ import (
 	"github.com/naser-989/xray-knife/v3/cmd/proxy"
 	"os"
 
+	"github.com/naser-989/xray-knife/v3/cmd/convert"
+	"github.com/naser-989/xray-knife/v3/cmd/cores"
+	"github.com/naser-989/xray-knife/v3/cmd/dedupe"
+	"github.com/naser-989/xray-knife/v3/cmd/lint"
 	"github.com/naser-989/xray-knife/v3/cmd/net"
 	"github.com/naser-989/xray-knife/v3/cmd/parse"
 	"github.com/naser-989/xray-knife/v3/cmd/scan"
 	"github.com/naser-989/xray-knife/v3/cmd/subs"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
 	"github.com/spf13/cobra"
 )
 
@@ -22,11 +27,27 @@ var rootCmd = &cobra.Command{
 	//2. net: Multiple network tests for xray configs.
 	//3. bot: A service to automatically switch outbound connections from a subscription or a file of configs.
 
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		protocol.SetRedact(redact)
+
+		if err := mux.Validate(); err != nil {
+			return err
+		}
+		protocol.SetMux(mux)
+		return nil
+	},
+
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
 }
 
+// redact masks the secrets of the configs in every output (--redact)
+var redact bool
+
+// mux is the multiplexing of the outbounds whose link doesn't set it (--mux flags)
+var mux protocol.Mux
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -42,8 +63,22 @@ func addSubcommandPalettes() {
 	rootCmd.AddCommand(net.NetCmd)
 	rootCmd.AddCommand(scan.ScanCmd)
 	rootCmd.AddCommand(proxy.ProxyCmd)
+	rootCmd.AddCommand(convert.ConvertCmd)
+	rootCmd.AddCommand(lint.LintCmd)
+	rootCmd.AddCommand(dedupe.DedupeCmd)
+	rootCmd.AddCommand(cores.CoresCmd)
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&redact, "redact", false, "Mask the secrets (UUIDs, passwords, private keys, REALITY short ids) in the output, keeping a short hash of them")
+
+	flags := rootCmd.PersistentFlags()
+	flags.BoolVar(&mux.Enabled, "mux", false, "Enable the multiplexing of the outbounds (xray mux, sing-box multiplex), links can override it with mux=0/1")
+	flags.IntVar(&mux.Concurrency, "mux-concurrency", 0, "Mux concurrency (xray) or max streams (sing-box), -1 to only multiplex UDP over XUDP (xray)")
+	flags.IntVar(&mux.XudpConcurrency, "xudp-concurrency", 0, "XUDP concurrency of the xray mux")
+	flags.StringVar(&mux.XudpProxyUDP443, "xudp-proxy-udp443", "", "XUDP handling of UDP/443 (QUIC) in the xray mux: reject, allow or skip")
+	flags.StringVar(&mux.Protocol, "mux-protocol", "", "sing-box multiplex protocol: smux, yamux or h2mux")
+	flags.BoolVar(&mux.Padding, "mux-padding", false, "Enable the padding of the sing-box multiplex")
+
 	addSubcommandPalettes()
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
 	"github.com/naser-989/xray-knife/v3/utils"
 	"github.com/naser-989/xray-knife/v3/utils/customlog"
 	"github.com/spf13/cobra"
@@ -17,6 +18,7 @@ type FetchConfig struct {
 	HTTPMethod      string
 	UserAgent       string
 	OutputFile      string
+	Dedupe          bool
 }
 
 // FetchCommand encapsulates the fetch command functionality
@@ -41,7 +43,8 @@ func (fc *FetchCommand) createCommand() *cobra.Command {
   --url, -u: subscription url
   --method, -m: http method to be used
   --out, -o: output file
-  --useragent, -x: useragent to be used`,
+  --useragent, -x: useragent to be used
+  --dedupe, -d: remove the duplicate configs (same server, credential and transport)`,
 		RunE: fc.runCommand,
 	}
 
@@ -57,6 +60,7 @@ func (fc *FetchCommand) addFlags(cmd *cobra.Command) {
 	flags.StringVarP(&fc.config.HTTPMethod, "method", "m", "GET", "Http method to be used")
 	flags.StringVarP(&fc.config.UserAgent, "useragent", "x", "", "Useragent to be used")
 	flags.StringVarP(&fc.config.OutputFile, "out", "o", "configs.txt", "The output file where the configs will be placed")
+	flags.BoolVarP(&fc.config.Dedupe, "dedupe", "d", false, "Remove the duplicate configs (same server, credential and transport)")
 }
 
 // runCommand executes the fetch command logic
@@ -80,6 +84,12 @@ func (fc *FetchCommand) runCommand(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to fetch configurations: %w", err)
 	}
 
+	if fc.config.Dedupe {
+		var duplicates int
+		configs, duplicates = protocol.Dedupe(configs, make(map[string]bool))
+		customlog.Printf(customlog.Processing, "%s: %d duplicates collapsed\n", sub.Url, duplicates)
+	}
+
 	if err := fc.saveConfigs(configs); err != nil {
 		return fmt.Errorf("failed to save configurations: %w", err)
 	}
@@ -7,6 +7,8 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/naser-989/xray-knife/v3/pkg"
+	"github.com/naser-989/xray-knife/v3/pkg/clash"
 	"github.com/naser-989/xray-knife/v3/utils"
 	"github.com/naser-989/xray-knife/v3/utils/customlog"
 )
@@ -39,6 +41,16 @@ func (s *Subscription) FetchAll() ([]string, error) {
 	}
 
 	bytes, _ := io.ReadAll(response.Body)
+	if clash.IsConfig(bytes) {
+		customlog.Printf(customlog.Processing, "Subscription is a Clash config, converting its proxies into links...\n")
+		links, err := pkg.ClashLinks(bytes)
+		if err != nil {
+			return nil, err
+		}
+		s.ConfigLinks = links
+		return links, nil
+	}
+
 	decoded, err2 := utils.Base64Decode(string(bytes))
 	if err2 != nil {
 		// Probably It's not base64 encoded!, let's try parsing without decoding
@@ -0,0 +1,535 @@
+package clash
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/naser-989/xray-knife/v3/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the part of a Clash / Mihomo config that holds the proxies
+type Config struct {
+	Proxies []Proxy `yaml:"proxies"`
+}
+
+// Proxy is a single entry of the "proxies" list.
+// Only the fields that have a counterpart in the share links are kept.
+type Proxy struct {
+	Name   string `yaml:"name"`
+	Type   string `yaml:"type"`
+	Server string `yaml:"server"`
+	Port   int    `yaml:"port"`
+	UDP    bool   `yaml:"udp,omitempty"`
+
+	UUID     string `yaml:"uuid,omitempty"`    // vmess, vless
+	AlterID  int    `yaml:"alterId,omitempty"` // vmess
+	Cipher   string `yaml:"cipher,omitempty"`  // vmess security, ss method
+	Flow     string `yaml:"flow,omitempty"`    // vless
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+
+	TLS               bool     `yaml:"tls,omitempty"`
+	SNI               string   `yaml:"sni,omitempty"`        // trojan, hysteria2
+	ServerName        string   `yaml:"servername,omitempty"` // vmess, vless
+	SkipCertVerify    bool     `yaml:"skip-cert-verify,omitempty"`
+	ClientFingerprint string   `yaml:"client-fingerprint,omitempty"`
+	ALPN              []string `yaml:"alpn,omitempty"`
+
+	Network     string          `yaml:"network,omitempty"`
+	WSOpts      *WSOptions      `yaml:"ws-opts,omitempty"`
+	HTTPOpts    *HTTPOptions    `yaml:"http-opts,omitempty"`
+	H2Opts      *H2Options      `yaml:"h2-opts,omitempty"`
+	GrpcOpts    *GrpcOptions    `yaml:"grpc-opts,omitempty"`
+	RealityOpts *RealityOptions `yaml:"reality-opts,omitempty"`
+
+	Plugin     string                 `yaml:"plugin,omitempty"` // ss
+	PluginOpts map[string]interface{} `yaml:"plugin-opts,omitempty"`
+
+	Obfs         string `yaml:"obfs,omitempty"` // hysteria2
+	ObfsPassword string `yaml:"obfs-password,omitempty"`
+	Ports        string `yaml:"ports,omitempty"`
+	Up           string `yaml:"up,omitempty"`
+	Down         string `yaml:"down,omitempty"`
+
+	IP           string   `yaml:"ip,omitempty"` // wireguard
+	IPv6         string   `yaml:"ipv6,omitempty"`
+	PrivateKey   string   `yaml:"private-key,omitempty"`
+	PublicKey    string   `yaml:"public-key,omitempty"`
+	PreSharedKey string   `yaml:"pre-shared-key,omitempty"`
+	Reserved     Reserved `yaml:"reserved,omitempty"`
+	MTU          int      `yaml:"mtu,omitempty"`
+}
+
+type WSOptions struct {
+	Path                string            `yaml:"path,omitempty"`
+	Headers             map[string]string `yaml:"headers,omitempty"`
+	MaxEarlyData        int               `yaml:"max-early-data,omitempty"`
+	EarlyDataHeaderName string            `yaml:"early-data-header-name,omitempty"`
+	V2rayHTTPUpgrade    bool              `yaml:"v2ray-http-upgrade,omitempty"`
+}
+
+// HTTPOptions is the HTTP header obfuscation of plain TCP
+type HTTPOptions struct {
+	Method  string              `yaml:"method,omitempty"`
+	Path    []string            `yaml:"path,omitempty"`
+	Headers map[string][]string `yaml:"headers,omitempty"`
+}
+
+type H2Options struct {
+	Host []string `yaml:"host,omitempty"`
+	Path string   `yaml:"path,omitempty"`
+}
+
+type GrpcOptions struct {
+	GrpcServiceName string `yaml:"grpc-service-name,omitempty"`
+}
+
+type RealityOptions struct {
+	PublicKey string `yaml:"public-key,omitempty"`
+	ShortID   string `yaml:"short-id,omitempty"`
+}
+
+// Reserved holds the wireguard reserved bytes, written either as a list
+// of numbers or as a base64 string
+type Reserved []int
+
+func (r *Reserved) UnmarshalYAML(value *yaml.Node) error {
+	var list []int
+	if err := value.Decode(&list); err == nil {
+		*r = list
+		return nil
+	}
+
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("invalid reserved value %q", s)
+	}
+	for _, b := range decoded {
+		*r = append(*r, int(b))
+	}
+	return nil
+}
+
+var proxiesPattern = regexp.MustCompile(`(?m)^proxies:`)
+
+// IsConfig reports whether data looks like a Clash YAML config
+func IsConfig(data []byte) bool {
+	return proxiesPattern.Match(data)
+}
+
+// LoadProxies reads a Clash / Mihomo YAML config and turns every entry of its
+// "proxies" list into the matching protocol struct.
+// Proxies that can't be mapped are skipped and reported in the returned error slice.
+func LoadProxies(data []byte) ([]protocol.Protocol, []error) {
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, []error{err}
+	}
+
+	var protocols []protocol.Protocol
+	var errs []error
+	for i := range config.Proxies {
+		proxy := &config.Proxies[i]
+		p, err := NewFromProxy(proxy)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("proxy #%d (%s, name %q): %v", i+1, proxy.Type, proxy.Name, err))
+			continue
+		}
+		protocols = append(protocols, p)
+	}
+
+	return protocols, errs
+}
+
+// NewFromProxy converts a single Clash proxy into its protocol struct.
+// The OrigLink of the result is set to its share link.
+func NewFromProxy(p *Proxy) (protocol.Protocol, error) {
+	if p.Server == "" || p.Port == 0 {
+		return nil, errors.New("proxy has no server")
+	}
+
+	switch p.Type {
+	case "vmess":
+		return p.toVmess()
+	case "vless":
+		return p.toVless()
+	case "trojan":
+		return p.toTrojan()
+	case "ss":
+		return p.toShadowsocks()
+	case "socks5":
+		return p.toSocks()
+	case "http":
+		return p.toHttp()
+	case "wireguard":
+		return p.toWireguard()
+	case "hysteria2":
+		return p.toHysteria2()
+	default:
+		return nil, fmt.Errorf("unsupported proxy type %q", p.Type)
+	}
+}
+
+// address returns the server the way Parse stores it
+func (p *Proxy) address() string {
+	if utils.IsIPv6(p.Server) {
+		return "[" + p.Server + "]"
+	}
+	return p.Server
+}
+
+func (p *Proxy) port() string {
+	return strconv.Itoa(p.Port)
+}
+
+func (p *Proxy) sni() string {
+	if p.SNI != "" {
+		return p.SNI
+	}
+	return p.ServerName
+}
+
+func (p *Proxy) insecure() string {
+	if p.SkipCertVerify {
+		return "1"
+	}
+	return ""
+}
+
+// security returns the security of the link, tls is forced for the
+// proxy types that are always wrapped in TLS
+func (p *Proxy) security(forceTLS bool) string {
+	if p.RealityOpts != nil {
+		return "reality"
+	}
+	if p.TLS || forceTLS {
+		return "tls"
+	}
+	return ""
+}
+
+// transportFields is the network of a proxy flattened into the share link parameters
+type transportFields struct {
+	Network     string
+	HeaderType  string
+	Host        string
+	Path        string
+	ServiceName string
+}
+
+func (p *Proxy) transport() (f transportFields, err error) {
+	switch p.Network {
+	case "", "tcp":
+		f.Network = "tcp"
+	case "ws":
+		f.Network = "ws"
+		if p.WSOpts != nil {
+			if p.WSOpts.V2rayHTTPUpgrade {
+				f.Network = "httpupgrade"
+			}
+			f.Path = p.WSOpts.Path
+			for name, value := range p.WSOpts.Headers {
+				if strings.EqualFold(name, "host") {
+					f.Host = value
+				}
+			}
+		}
+	case "http":
+		f.Network = "tcp"
+		f.HeaderType = "http"
+		if p.HTTPOpts != nil {
+			f.Path = strings.Join(p.HTTPOpts.Path, ",")
+			for name, values := range p.HTTPOpts.Headers {
+				if strings.EqualFold(name, "host") {
+					f.Host = strings.Join(values, ",")
+				}
+			}
+		}
+	case "h2":
+		f.Network = "http"
+		if p.H2Opts != nil {
+			f.Host = strings.Join(p.H2Opts.Host, ",")
+			f.Path = p.H2Opts.Path
+		}
+	case "grpc":
+		f.Network = "grpc"
+		if p.GrpcOpts != nil {
+			f.ServiceName = p.GrpcOpts.GrpcServiceName
+		}
+	default:
+		return f, fmt.Errorf("unsupported network %q", p.Network)
+	}
+
+	return f, nil
+}
+
+func (p *Proxy) toVmess() (protocol.Protocol, error) {
+	tr, err := p.transport()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &protocol.Vmess{
+		Version:        "2",
+		Address:        p.address(),
+		Aid:            strconv.Itoa(p.AlterID),
+		Port:           p.port(),
+		Security:       p.Cipher,
+		Host:           tr.Host,
+		ID:             p.UUID,
+		Network:        tr.Network,
+		Path:           tr.Path,
+		Remark:         p.Name,
+		TLS:            p.security(false),
+		AllowInsecure:  p.insecure(),
+		SNI:            p.sni(),
+		ALPN:           strings.Join(p.ALPN, ","),
+		TlsFingerprint: p.ClientFingerprint,
+		Type:           tr.HeaderType,
+	}
+	if v.Security == "" {
+		v.Security = "auto"
+	}
+	switch tr.Network {
+	case "http":
+		v.Network = "h2"
+	case "grpc":
+		v.Path = tr.ServiceName
+	}
+
+	v.OrigLink = v.ToLink()
+	return v, nil
+}
+
+func (p *Proxy) toVless() (protocol.Protocol, error) {
+	tr, err := p.transport()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &protocol.Vless{
+		ID:             p.UUID,
+		Address:        p.address(),
+		Encryption:     "none",
+		Flow:           p.Flow,
+		Security:       p.security(false),
+		HeaderType:     tr.HeaderType,
+		Host:           tr.Host,
+		Path:           tr.Path,
+		Port:           p.port(),
+		SNI:            p.sni(),
+		ALPN:           strings.Join(p.ALPN, ","),
+		TlsFingerprint: p.ClientFingerprint,
+		AllowInsecure:  p.insecure(),
+		Type:           tr.Network,
+		Remark:         p.Name,
+		ServiceName:    tr.ServiceName,
+	}
+	if p.RealityOpts != nil {
+		v.PublicKey = p.RealityOpts.PublicKey
+		v.ShortIds = p.RealityOpts.ShortID
+	}
+
+	v.OrigLink = v.ToLink()
+	return v, nil
+}
+
+func (p *Proxy) toTrojan() (protocol.Protocol, error) {
+	tr, err := p.transport()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &protocol.Trojan{
+		Password:       p.Password,
+		Address:        p.address(),
+		Flow:           p.Flow,
+		Security:       p.security(true),
+		HeaderType:     tr.HeaderType,
+		Host:           tr.Host,
+		Path:           tr.Path,
+		Port:           p.port(),
+		SNI:            p.sni(),
+		ALPN:           strings.Join(p.ALPN, ","),
+		TlsFingerprint: p.ClientFingerprint,
+		AllowInsecure:  p.insecure(),
+		Type:           tr.Network,
+		Remark:         p.Name,
+		ServiceName:    tr.ServiceName,
+	}
+	if p.RealityOpts != nil {
+		t.PublicKey = p.RealityOpts.PublicKey
+		t.ShortIds = p.RealityOpts.ShortID
+	}
+
+	t.OrigLink = t.ToLink()
+	return t, nil
+}
+
+func (p *Proxy) toShadowsocks() (protocol.Protocol, error) {
+	plugin, pluginOpts, err := p.sip002Plugin()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &protocol.Shadowsocks{
+		Address:    p.address(),
+		Port:       p.port(),
+		Encryption: p.Cipher,
+		Password:   p.Password,
+		Remark:     p.Name,
+		Plugin:     plugin,
+		PluginOpts: pluginOpts,
+	}
+
+	s.OrigLink = s.ToLink()
+	return s, nil
+}
+
+// sip002Plugin converts the Clash plugin and plugin-opts into the SIP002 plugin of ss links
+func (p *Proxy) sip002Plugin() (string, string, error) {
+	opt := func(name string) string {
+		if v, ok := p.PluginOpts[name]; ok {
+			return fmt.Sprintf("%v", v)
+		}
+		return ""
+	}
+
+	var opts []string
+	add := func(key string, value string) {
+		if value != "" {
+			opts = append(opts, key+"="+value)
+		}
+	}
+
+	switch p.Plugin {
+	case "":
+		return "", "", nil
+	case "obfs":
+		add("obfs", opt("mode"))
+		add("obfs-host", opt("host"))
+		return "obfs-local", strings.Join(opts, ";"), nil
+	case "v2ray-plugin":
+		add("mode", opt("mode"))
+		if opt("tls") == "true" {
+			opts = append(opts, "tls")
+		}
+		add("host", opt("host"))
+		add("path", opt("path"))
+		if opt("mux") == "false" {
+			add("mux", "0")
+		}
+		return "v2ray-plugin", strings.Join(opts, ";"), nil
+	case "shadow-tls":
+		add("host", opt("host"))
+		add("password", opt("password"))
+		add("version", opt("version"))
+		return "shadow-tls", strings.Join(opts, ";"), nil
+	default:
+		return "", "", fmt.Errorf("shadowsocks plugin %q is not supported", p.Plugin)
+	}
+}
+
+func (p *Proxy) toSocks() (protocol.Protocol, error) {
+	if p.TLS {
+		return nil, errors.New("socks5 over tls is not supported")
+	}
+
+	s := &protocol.Socks{
+		Remark:   p.Name,
+		Address:  p.address(),
+		Port:     p.port(),
+		Username: p.Username,
+		Password: p.Password,
+	}
+
+	s.OrigLink = s.ToLink()
+	return s, nil
+}
+
+func (p *Proxy) toHttp() (protocol.Protocol, error) {
+	// Http.Parse keeps IPv6 addresses unbracketed
+	h := &protocol.Http{
+		Remark:   p.Name,
+		Address:  p.Server,
+		Port:     p.port(),
+		Username: p.Username,
+		Password: p.Password,
+	}
+	if p.TLS {
+		h.TLS = "tls"
+		h.SNI = p.sni()
+		h.AllowInsecure = p.insecure()
+	}
+
+	h.OrigLink = h.ToLink()
+	return h, nil
+}
+
+func (p *Proxy) toWireguard() (protocol.Protocol, error) {
+	var localAddresses []string
+	if p.IP != "" {
+		localAddresses = append(localAddresses, withPrefix(p.IP, "/32"))
+	}
+	if p.IPv6 != "" {
+		localAddresses = append(localAddresses, withPrefix(p.IPv6, "/128"))
+	}
+
+	var reserved []string
+	for _, v := range p.Reserved {
+		reserved = append(reserved, strconv.Itoa(v))
+	}
+
+	w := &protocol.Wireguard{
+		Remark:       p.Name,
+		PublicKey:    p.PublicKey,
+		SecretKey:    p.PrivateKey,
+		PreSharedKey: p.PreSharedKey,
+		Endpoint:     protocol.JoinHostPort(p.Server, p.port()),
+		Reserved:     strings.Join(reserved, ","),
+		LocalAddress: strings.Join(localAddresses, ","),
+		Mtu:          int32(p.MTU),
+	}
+
+	w.OrigLink = w.ToLink()
+	return w, nil
+}
+
+// withPrefix appends a prefix length to a bare IP address
+func withPrefix(ip string, prefix string) string {
+	if strings.Contains(ip, "/") {
+		return ip
+	}
+	return ip + prefix
+}
+
+func (p *Proxy) toHysteria2() (protocol.Protocol, error) {
+	// Hysteria2.Parse keeps IPv6 addresses unbracketed
+	h := &protocol.Hysteria2{
+		Remark:        p.Name,
+		Address:       p.Server,
+		Port:          p.port(),
+		Password:      p.Password,
+		ObfusType:     p.Obfs,
+		ObfusPassword: p.ObfsPassword,
+		SNI:           p.sni(),
+		Insecure:      p.insecure(),
+		MPort:         p.Ports,
+		Up:            p.Up,
+		Down:          p.Down,
+		ALPN:          strings.Join(p.ALPN, ","),
+	}
+
+	h.OrigLink = h.ToLink()
+	return h, nil
+}
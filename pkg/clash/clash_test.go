@@ -0,0 +1,168 @@
+package clash
+
+import (
+	"testing"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoadProxies(t *testing.T) {
+	config := `
+port: 7890
+mode: rule
+proxies:
+  - name: vmess-ws
+    type: vmess
+    server: example.com
+    port: 443
+    uuid: 0090bbba-1118-46ca-87a1-52599cee74ab
+    alterId: 0
+    cipher: auto
+    tls: true
+    servername: example.com
+    network: ws
+    ws-opts:
+      path: /ws
+      headers:
+        Host: cdn.example.com
+  - name: vless-reality
+    type: vless
+    server: 1.2.3.4
+    port: 443
+    uuid: 0090bbba-1118-46ca-87a1-52599cee74ab
+    flow: xtls-rprx-vision
+    tls: true
+    servername: www.speedtest.net
+    client-fingerprint: chrome
+    reality-opts:
+      public-key: 7xhH4b_VkliBxGulljcyPOH-bYUA2dl-XAdZAsfhk2U
+      short-id: 6ba85179e30d4fc2
+  - name: trojan-grpc
+    type: trojan
+    server: example.com
+    port: 443
+    password: secret
+    sni: example.com
+    network: grpc
+    grpc-opts:
+      grpc-service-name: grpc
+  - name: ss
+    type: ss
+    server: 1.2.3.4
+    port: 8388
+    cipher: chacha20-ietf-poly1305
+    password: secret
+  - name: hy2
+    type: hysteria2
+    server: example.com
+    port: 8443
+    password: secret
+    obfs: salamander
+    obfs-password: obfs
+    skip-cert-verify: true
+  - name: warp
+    type: wireguard
+    server: 162.159.192.1
+    port: 2408
+    ip: 172.16.0.2
+    private-key: eCtXsJZ27+4PbhDkHnB923tkUn2Gj59wZw5wFA75MnU=
+    public-key: bmXOC+F1FxEMF9dyiK2H5/1SUtzH0JuVo51h2wPfgyo=
+    reserved: [1, 2, 3]
+    mtu: 1280
+  - name: http-auth
+    type: http
+    server: example.com
+    port: 8443
+    username: user
+    password: p@ss
+    tls: true
+    sni: proxy.example.com
+    skip-cert-verify: true
+  - name: ss-obfs
+    type: ss
+    server: 1.2.3.4
+    port: 8388
+    cipher: aes-256-gcm
+    password: secret
+    plugin: obfs
+    plugin-opts:
+      mode: tls
+      host: www.bing.com
+  - name: snell
+    type: snell
+    server: example.com
+    port: 443
+rules:
+  - MATCH,DIRECT
+`
+	if !IsConfig([]byte(config)) {
+		t.Fatal("config not detected as a Clash config")
+	}
+
+	protocols, errs := LoadProxies([]byte(config))
+	if len(protocols) != 8 {
+		t.Fatalf("expected 8 proxies, got %d (errors: %v)", len(protocols), errs)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected the snell proxy to be reported, got %v", errs)
+	}
+
+	vmess := protocols[0].(*protocol.Vmess)
+	if vmess.Network != "ws" || vmess.Path != "/ws" || vmess.Host != "cdn.example.com" || vmess.TLS != "tls" {
+		t.Errorf("unexpected vmess proxy: %+v", vmess)
+	}
+
+	vless := protocols[1].(*protocol.Vless)
+	if vless.Security != "reality" || vless.PublicKey == "" || vless.ShortIds != "6ba85179e30d4fc2" || vless.TlsFingerprint != "chrome" {
+		t.Errorf("unexpected vless proxy: %+v", vless)
+	}
+
+	trojan := protocols[2].(*protocol.Trojan)
+	if trojan.Type != "grpc" || trojan.ServiceName != "grpc" || trojan.Security != "tls" {
+		t.Errorf("unexpected trojan proxy: %+v", trojan)
+	}
+
+	wg := protocols[5].(*protocol.Wireguard)
+	if wg.LocalAddress != "172.16.0.2/32" || wg.Reserved != "1,2,3" || wg.Endpoint != "162.159.192.1:2408" {
+		t.Errorf("unexpected wireguard proxy: %+v", wg)
+	}
+
+	h := protocols[6].(*protocol.Http)
+	if h.Username != "user" || h.Password != "p@ss" || h.TLS != "tls" || h.SNI != "proxy.example.com" || h.AllowInsecure != "1" {
+		t.Errorf("unexpected http proxy: %+v", h)
+	}
+
+	ssObfs := protocols[7].(*protocol.Shadowsocks)
+	if ssObfs.Plugin != "obfs-local" || ssObfs.PluginOpts != "obfs=tls;obfs-host=www.bing.com" {
+		t.Errorf("unexpected shadowsocks proxy: %+v", ssObfs)
+	}
+	back, err := FromProtocol(ssObfs)
+	if err != nil || back.Plugin != "obfs" || back.PluginOpts["mode"] != "tls" || back.PluginOpts["host"] != "www.bing.com" {
+		t.Errorf("unexpected exported shadowsocks proxy: %+v, %v", back, err)
+	}
+
+	// The generated links must be parsable like any other link
+	for _, p := range protocols {
+		link := p.ToLink()
+		parsed, err := protocol.CreateProtocol(link)
+		if err != nil {
+			t.Fatalf("%s: %v", link, err)
+		}
+		if err = parsed.Parse(); err != nil {
+			t.Errorf("%s: %v", link, err)
+		}
+	}
+}
+
+func TestReserved_UnmarshalYAML(t *testing.T) {
+	for _, config := range []string{"reserved: [1, 2, 3]", "reserved: AQID"} {
+		var p Proxy
+		if err := yaml.Unmarshal([]byte(config), &p); err != nil {
+			t.Fatalf("%s: %v", config, err)
+		}
+		if len(p.Reserved) != 3 || p.Reserved[0] != 1 || p.Reserved[2] != 3 {
+			t.Errorf("%s: unexpected reserved %v", config, p.Reserved)
+		}
+	}
+}
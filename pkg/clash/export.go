@@ -0,0 +1,319 @@
+package clash
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+)
+
+// FromProtocol converts a parsed protocol into a Clash proxy.
+// It's the reverse of NewFromProxy, so it accepts the same protocol structs.
+func FromProtocol(p protocol.Protocol) (*Proxy, error) {
+	switch v := p.(type) {
+	case *protocol.Vmess:
+		return fromVmess(v)
+	case *protocol.Vless:
+		return fromVless(v)
+	case *protocol.Trojan:
+		return fromTrojan(v)
+	case *protocol.Shadowsocks:
+		return fromShadowsocks(v)
+	case *protocol.Socks:
+		return fromSocks(v)
+	case *protocol.Http:
+		return fromHttp(v)
+	case *protocol.Wireguard:
+		return fromWireguard(v)
+	case *protocol.Hysteria2:
+		return fromHysteria2(v)
+	default:
+		return nil, fmt.Errorf("unsupported protocol %T", p)
+	}
+}
+
+func newProxy(proxyType string, remark string, address string, port string) (*Proxy, error) {
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", port)
+	}
+
+	return &Proxy{
+		Name:   remark,
+		Type:   proxyType,
+		Server: strings.Trim(address, "[]"),
+		Port:   portNum,
+	}, nil
+}
+
+func isTrue(v interface{}) bool {
+	s := fmt.Sprintf("%v", v)
+	return s == "1" || s == "true"
+}
+
+func splitList(s string) []string {
+	if s == "" || s == "none" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// setTLS fills the TLS fields from the share link parameters
+func (p *Proxy) setTLS(security string, sni string, alpn string, fingerprint string, insecure interface{}) {
+	if security != "tls" && security != "reality" {
+		return
+	}
+
+	p.TLS = true
+	p.ALPN = splitList(alpn)
+	p.SkipCertVerify = isTrue(insecure)
+	if fingerprint != "none" {
+		p.ClientFingerprint = fingerprint
+	}
+	if p.Type == "trojan" || p.Type == "http" {
+		p.SNI = sni
+	} else {
+		p.ServerName = sni
+	}
+}
+
+// setTransport fills the network and its options from the share link parameters
+func (p *Proxy) setTransport(network string, headerType string, host string, path string, serviceName string) error {
+	switch network {
+	case "", "tcp":
+		if headerType == "http" {
+			p.Network = "http"
+			p.HTTPOpts = &HTTPOptions{Method: "GET", Path: splitList(path)}
+			if host != "" {
+				p.HTTPOpts.Headers = map[string][]string{"Host": splitList(host)}
+			}
+		}
+	case "ws", "httpupgrade":
+		p.Network = "ws"
+		p.WSOpts = &WSOptions{Path: path, V2rayHTTPUpgrade: network == "httpupgrade"}
+		if host != "" {
+			p.WSOpts.Headers = map[string]string{"Host": host}
+		}
+	case "http", "h2":
+		p.Network = "h2"
+		p.H2Opts = &H2Options{Host: splitList(host), Path: path}
+	case "grpc":
+		p.Network = "grpc"
+		p.GrpcOpts = &GrpcOptions{GrpcServiceName: serviceName}
+	default:
+		return fmt.Errorf("network %q is not supported by clash", network)
+	}
+
+	return nil
+}
+
+func fromVmess(v *protocol.Vmess) (*Proxy, error) {
+	p, err := newProxy("vmess", v.Remark, v.Address, fmt.Sprintf("%v", v.Port))
+	if err != nil {
+		return nil, err
+	}
+	p.UUID = v.ID
+	p.AlterID, _ = strconv.Atoi(fmt.Sprintf("%v", v.Aid))
+	p.Cipher = v.Security
+	if p.Cipher == "" {
+		p.Cipher = "auto"
+	}
+	p.setTLS(v.TLS, v.SNI, v.ALPN, v.TlsFingerprint, v.AllowInsecure)
+
+	serviceName := ""
+	if v.Network == "grpc" {
+		serviceName = v.Path
+	}
+	if err = p.setTransport(v.Network, v.Type, v.Host, v.Path, serviceName); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func fromVless(v *protocol.Vless) (*Proxy, error) {
+	p, err := newProxy("vless", v.Remark, v.Address, v.Port)
+	if err != nil {
+		return nil, err
+	}
+	p.UUID = v.ID
+	p.Flow = v.Flow
+	p.setTLS(v.Security, v.SNI, v.ALPN, v.TlsFingerprint, v.AllowInsecure)
+	if v.Security == "reality" {
+		p.RealityOpts = &RealityOptions{PublicKey: v.PublicKey, ShortID: v.ShortIds}
+	}
+	if err = p.setTransport(v.Type, v.HeaderType, v.Host, v.Path, v.ServiceName); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func fromTrojan(t *protocol.Trojan) (*Proxy, error) {
+	if t.Security != "tls" && t.Security != "reality" {
+		return nil, errors.New("trojan without tls is not supported by clash")
+	}
+
+	p, err := newProxy("trojan", t.Remark, t.Address, t.Port)
+	if err != nil {
+		return nil, err
+	}
+	p.Password = t.Password
+	p.Flow = t.Flow
+	p.setTLS(t.Security, t.SNI, t.ALPN, t.TlsFingerprint, t.AllowInsecure)
+	// Trojan is always wrapped in TLS, the field is left out like in most Clash configs
+	p.TLS = false
+	if t.Security == "reality" {
+		p.RealityOpts = &RealityOptions{PublicKey: t.PublicKey, ShortID: t.ShortIds}
+	}
+	if err = p.setTransport(t.Type, t.HeaderType, t.Host, t.Path, t.ServiceName); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func fromShadowsocks(s *protocol.Shadowsocks) (*Proxy, error) {
+	p, err := newProxy("ss", s.Remark, s.Address, s.Port)
+	if err != nil {
+		return nil, err
+	}
+	p.Cipher = s.Encryption
+	p.Password = s.Password
+
+	if err = p.setPlugin(s.Plugin, s.PluginOpts); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// setPlugin fills the Clash plugin and plugin-opts from the SIP002 plugin of ss links
+func (p *Proxy) setPlugin(plugin string, pluginOpts string) error {
+	opts := protocol.ParsePluginOpts(pluginOpts)
+
+	switch plugin {
+	case "":
+		return nil
+	case "obfs-local", "simple-obfs":
+		p.Plugin = "obfs"
+		p.PluginOpts = map[string]interface{}{"mode": "http"}
+		if mode := opts["obfs"]; mode != "" {
+			p.PluginOpts["mode"] = mode
+		}
+		if host := opts["obfs-host"]; host != "" {
+			p.PluginOpts["host"] = host
+		}
+	case "v2ray-plugin":
+		p.Plugin = plugin
+		p.PluginOpts = map[string]interface{}{"mode": "websocket"}
+		if mode := opts["mode"]; mode != "" {
+			p.PluginOpts["mode"] = mode
+		}
+		if _, ok := opts["tls"]; ok {
+			p.PluginOpts["tls"] = true
+		}
+		if host := opts["host"]; host != "" {
+			p.PluginOpts["host"] = host
+		}
+		if path := opts["path"]; path != "" {
+			p.PluginOpts["path"] = path
+		}
+		if mux, ok := opts["mux"]; ok {
+			p.PluginOpts["mux"] = mux != "0"
+		}
+	case "shadow-tls":
+		p.Plugin = plugin
+		p.PluginOpts = map[string]interface{}{"host": opts["host"]}
+		if password := opts["password"]; password != "" {
+			p.PluginOpts["password"] = password
+		}
+		if version, err := strconv.Atoi(opts["version"]); err == nil {
+			p.PluginOpts["version"] = version
+		}
+	default:
+		return fmt.Errorf("shadowsocks plugin %q is not supported", plugin)
+	}
+
+	return nil
+}
+
+func fromSocks(s *protocol.Socks) (*Proxy, error) {
+	p, err := newProxy("socks5", s.Remark, s.Address, s.Port)
+	if err != nil {
+		return nil, err
+	}
+	p.Username = s.Username
+	p.Password = s.Password
+
+	return p, nil
+}
+
+func fromHttp(h *protocol.Http) (*Proxy, error) {
+	p, err := newProxy("http", h.Remark, h.Address, h.Port)
+	if err != nil {
+		return nil, err
+	}
+	p.Username = h.Username
+	p.Password = h.Password
+	// Clash http proxies have no client fingerprint
+	p.setTLS(h.TLS, h.SNI, "", "", h.AllowInsecure)
+
+	return p, nil
+}
+
+func fromWireguard(w *protocol.Wireguard) (*Proxy, error) {
+	address, port, err := net.SplitHostPort(w.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	p, err := newProxy("wireguard", w.Remark, address, port)
+	if err != nil {
+		return nil, err
+	}
+	p.PrivateKey = w.SecretKey
+	p.PublicKey = w.PublicKey
+	p.PreSharedKey = w.PreSharedKey
+	p.MTU = int(w.Mtu)
+
+	// Clash takes one bare address per family
+	for _, prefix := range splitList(w.LocalAddress) {
+		ip, _, _ := strings.Cut(strings.TrimSpace(prefix), "/")
+		if strings.Contains(ip, ":") {
+			p.IPv6 = ip
+		} else {
+			p.IP = ip
+		}
+	}
+
+	for _, v := range splitList(w.Reserved) {
+		num, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid reserved value %q", w.Reserved)
+		}
+		p.Reserved = append(p.Reserved, num)
+	}
+
+	return p, nil
+}
+
+func fromHysteria2(h *protocol.Hysteria2) (*Proxy, error) {
+	p, err := newProxy("hysteria2", h.Remark, h.Address, h.Port)
+	if err != nil {
+		return nil, err
+	}
+	p.Password = h.Password
+	p.Obfs = h.ObfusType
+	p.ObfsPassword = h.ObfusPassword
+	p.SNI = h.SNI
+	p.SkipCertVerify = isTrue(h.Insecure)
+	p.Ports = h.MPort
+	p.Up = h.Up
+	p.Down = h.Down
+	p.ALPN = splitList(h.ALPN)
+
+	return p, nil
+}
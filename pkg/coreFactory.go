@@ -1,10 +1,14 @@
 package pkg
 
 import (
+	"fmt"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
 	"github.com/naser-989/xray-knife/v3/pkg/singbox"
 	"github.com/naser-989/xray-knife/v3/pkg/xray"
+	"github.com/naser-989/xray-knife/v3/utils/customlog"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -18,7 +22,10 @@ const (
 
 // Core interface that both xray-Core and sing-box must implement
 type Core interface {
+	protocol.Builder
+
 	Name() string
+	Capabilities() protocol.Capabilities
 	MakeHttpClient(outbound protocol.Protocol, maxDelay time.Duration) (*http.Client, protocol.Instance, error)
 	CreateProtocol(protocolType string) (protocol.Protocol, error)
 
@@ -33,66 +40,291 @@ func CoreFactory(coreType CoreType, insecureTLS bool, verbose bool) Core {
 		return xray.NewXrayService(verbose, insecureTLS)
 	case SingboxCoreType:
 		return singbox.NewSingboxService(verbose, insecureTLS)
-	//case AutoCoreType:
-	//	return NewAutomaticCore(false, false)
+	case AutoCoreType:
+		return NewAutomaticCore(verbose, insecureTLS)
 	default:
 		return nil
 	}
 }
 
+// coreTypeNames are the names of the core types on the command line (-z)
+var coreTypeNames = map[string]CoreType{
+	"xray":    XrayCoreType,
+	"singbox": SingboxCoreType,
+	"auto":    AutoCoreType,
+}
+
+// ParseCoreType returns the core type of a name (xray, singbox or auto)
+func ParseCoreType(name string) (CoreType, error) {
+	coreType, ok := coreTypeNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown core type %q (allowed: auto, xray, singbox)", name)
+	}
+	return coreType, nil
+}
+
 // AutomaticCore implementation of the Core interface
-// Selects Core based on the config link
-//type AutomaticCore struct {
-//	xrayCore 		*xray.Core
-//	singboxCore 	*singbox.Core
-//}
-//
-//
-//func (c *AutomaticCore) Name() string {
-//	return "Automatic"
-//}
-//
-//func NewAutomaticCore(verbose bool, allowInsecure bool) *AutomaticCore {
-//	return &AutomaticCore{
-//		xrayCore: xray.NewXrayService(verbose, allowInsecure),
-//		singboxCore: singbox.NewSingboxService(verbose, allowInsecure),
-//	}
-//}
-//
-//
-//// Defined Core for each protocol
-//var cproto = map[string]string{
-//	protocol.VmessIdentifier:       "xray",
-//	protocol.VlessIdentifier:       "xray",
-//	protocol.ShadowsocksIdentifier: "xray",
-//	protocol.TrojanIdentifier:      "xray",
-//	protocol.SocksIdentifier:       "xray",
-//	protocol.WireguardIdentifier:   "xray",
-//	protocol.Hysteria2Identifier:   "singbox",
-//}
-//
-//func (c *AutomaticCore) CreateProtocol(configLink string) (protocol.Protocol, error) {
-//	// Parse url
-//	uri, err := url.Parse(configLink)
-//	if err != nil {
-//		return nil, err
-//	}
-//
-//	coreType, ok := cproto[uri.Scheme]
-//	if !ok {
-//		return nil, errors.New(fmt.Sprintf("invalid %s protocol", coreType))
-//	}
-//
-//	switch coreType {
-//	case "xray":
-//		return c.xrayCore.CreateProtocol(configLink)
-//	case "singbox":
-//		return c.singboxCore.CreateProtocol(configLink)
-//	default: // TODO: What?
-//		return c.singboxCore.CreateProtocol(configLink)
-//	}
-//}
-
-//func (c *AutomaticCore) MakeHttpClient(outbound protocol.Protocol) (*http.Client, protocol.Instance, error) {
-//	return outbound.
-//}
+// Selects the core of each config from its link scheme: the core the scheme is registered to
+// (see protocol.Register), unless a route overrides it.
+// Shadowsocks links with a SIP002 plugin xray can't express (all but v2ray-plugin in websocket mode) go to sing-box.
+type AutomaticCore struct {
+	xrayCore    Core
+	singboxCore Core
+
+	registry    *protocol.Registry
+	routes      map[string]CoreType // Overrides of the registered cores
+	xrayOptions bool                // Options were set on the xray core, see SetXrayOptions
+}
+
+func NewAutomaticCore(verbose bool, allowInsecure bool) *AutomaticCore {
+	return &AutomaticCore{
+		xrayCore:    xray.NewXrayService(verbose, allowInsecure),
+		singboxCore: singbox.NewSingboxService(verbose, allowInsecure),
+		registry:    protocol.DefaultRegistry(),
+		routes:      make(map[string]CoreType),
+	}
+}
+
+// SetRegistry replaces the registry of the schemes, the default one by default
+func (c *AutomaticCore) SetRegistry(registry *protocol.Registry) {
+	c.registry = registry
+}
+
+func (c *AutomaticCore) Name() string {
+	return "auto"
+}
+
+// Route overrides the core of a link scheme for this core only
+func (c *AutomaticCore) Route(scheme string, coreType CoreType) error {
+	if coreType == AutoCoreType {
+		return fmt.Errorf("%s links can't be routed to the automatic core", scheme)
+	}
+	c.routes[strings.ToLower(scheme)] = coreType
+	return nil
+}
+
+// SetRoutes overrides the routes given as scheme=core (e.g. socks=singbox)
+func (c *AutomaticCore) SetRoutes(routes []string) error {
+	for _, route := range routes {
+		scheme, name, ok := strings.Cut(route, "=")
+		if !ok {
+			return fmt.Errorf("invalid route %q, expected scheme=core", route)
+		}
+		coreType, err := ParseCoreType(name)
+		if err != nil {
+			return err
+		}
+		if err = c.Route(scheme, coreType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetCoreRoutes overrides the routes of an automatic core (see SetRoutes), the other cores take no routes
+func SetCoreRoutes(core Core, routes []string) error {
+	if len(routes) == 0 {
+		return nil
+	}
+	auto, ok := core.(*AutomaticCore)
+	if !ok {
+		return fmt.Errorf("routes only apply to the auto core, not %s", core.Name())
+	}
+	return auto.SetRoutes(routes)
+}
+
+// SetXrayOptions applies service options (e.g. xray.WithFragment) to the xray core of a core.
+// The sing-box core has none, the auto core applies them to the configs it runs with xray
+// and reports the configs that run on sing-box without them.
+func SetXrayOptions(core Core, opts ...xray.ServiceOption) error {
+	if len(opts) == 0 {
+		return nil
+	}
+	switch c := core.(type) {
+	case *xray.Core:
+		c.SetOptions(opts...)
+	case *AutomaticCore:
+		if err := SetXrayOptions(c.xrayCore, opts...); err != nil {
+			return err
+		}
+		c.xrayOptions = true
+	default:
+		return fmt.Errorf("xray options only apply to the xray and auto cores, not %s", core.Name())
+	}
+	return nil
+}
+
+func (c *AutomaticCore) Capabilities() protocol.Capabilities {
+	capabilities := c.xrayCore.Capabilities().Merge(c.singboxCore.Capabilities())
+	capabilities.Core = c.Name()
+	return capabilities
+}
+
+// SelectCore returns the core of a config link: the routed one, unless only the other core
+// supports the transport or the security of the config
+func (c *AutomaticCore) SelectCore(link string) (Core, error) {
+	core, err := c.route(link)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := c.registry.CreateProtocol(link)
+	if err == nil {
+		err = p.Parse()
+	}
+	if err != nil {
+		// The core reports the parse error
+		return core, nil
+	}
+	return c.capable(core, p), nil
+}
+
+// route returns the core of a link scheme, the overridden or the registered one
+func (c *AutomaticCore) route(link string) (Core, error) {
+	routed, _ := protocol.SplitHopPorts(strings.TrimSpace(link))
+	uri, err := url.Parse(routed)
+	if err != nil {
+		return nil, &protocol.ParseError{Reason: protocol.ReasonMalformedLink, Err: err}
+	}
+	if uri.Scheme == protocol.ShadowsocksIdentifier {
+		name, opts, _ := strings.Cut(uri.Query().Get("plugin"), ";")
+		if singboxOnlyPlugin(name, opts) {
+			return c.singboxCore, nil
+		}
+	}
+	return c.routeScheme(uri.Scheme)
+}
+
+// routeScheme returns the core of a registered scheme, the overridden or the registered one
+func (c *AutomaticCore) routeScheme(scheme string) (Core, error) {
+	_, registered, ok := c.registry.Lookup(scheme)
+	if !ok {
+		return nil, &protocol.ParseError{Scheme: scheme, Field: "scheme", Value: scheme, Reason: protocol.ReasonUnknownScheme}
+	}
+	coreType, overridden := c.routes[strings.ToLower(scheme)]
+	if !overridden {
+		coreType, _ = ParseCoreType(registered)
+	}
+	if coreType == SingboxCoreType {
+		return c.singboxCore, nil
+	}
+	return c.xrayCore, nil
+}
+
+// singboxOnlyPlugin reports whether a shadowsocks SIP002 plugin only runs on sing-box (e.g. obfs-local, shadow-tls).
+// xray runs v2ray-plugin in websocket mode as a ws stream.
+func singboxOnlyPlugin(name string, opts string) bool {
+	if name == "" {
+		return false
+	}
+	if name != "v2ray-plugin" {
+		return true
+	}
+	mode, ok := protocol.ParsePluginOpts(opts)["mode"]
+	return ok && mode != "websocket"
+}
+
+// Candidates returns the cores that may handle a config link: the routed one, then the other one as a fallback
+func (c *AutomaticCore) Candidates(link string) ([]Core, error) {
+	core, err := c.SelectCore(link)
+	if err != nil {
+		return nil, err
+	}
+	return []Core{core, c.other(core)}, nil
+}
+
+// other returns the core that is not core
+func (c *AutomaticCore) other(core Core) Core {
+	if core == c.xrayCore {
+		return c.singboxCore
+	}
+	return c.xrayCore
+}
+
+// capable returns the other core when only it supports the config
+func (c *AutomaticCore) capable(core Core, p protocol.Protocol) Core {
+	if other := c.other(core); core.Capabilities().Check(p) != nil && other.Capabilities().Check(p) == nil {
+		return other
+	}
+	return core
+}
+
+// coreOf returns the core of a protocol: the one that built it, or the one of its link or alias
+func (c *AutomaticCore) coreOf(p protocol.Protocol) (Core, error) {
+	switch p.(type) {
+	case xray.Protocol:
+		return c.xrayCore, nil
+	case singbox.Protocol:
+		return c.singboxCore, nil
+	}
+
+	var core Core
+	var err error
+	if alias := protocol.AliasOf(p); alias != "" {
+		// The protocols made by an alias go to the core of the alias rather than the one of their rewritten link
+		core, err = c.routeScheme(alias)
+	} else if link := p.ConvertToGeneralConfig().OrigLink; link != "" {
+		core, err = c.route(link)
+	} else {
+		// Protocols made in code (e.g. the proxy inbound) have no link
+		core, err = c.routeScheme(protocol.SchemeOf(p))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.capable(core, p), nil
+}
+
+func (c *AutomaticCore) CreateProtocol(configLink string) (protocol.Protocol, error) {
+	core, err := c.SelectCore(configLink)
+	if err != nil {
+		return nil, err
+	}
+	p, err := c.registry.CreateProtocol(configLink)
+	if err != nil {
+		return nil, err
+	}
+	return core.Build(p)
+}
+
+func (c *AutomaticCore) Build(p protocol.Protocol) (protocol.Protocol, error) {
+	core, err := c.coreOf(p)
+	if err != nil {
+		return nil, err
+	}
+	return core.Build(p)
+}
+
+func (c *AutomaticCore) MakeHttpClient(outbound protocol.Protocol, maxDelay time.Duration) (*http.Client, protocol.Instance, error) {
+	core, err := c.coreOf(outbound)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.warnXrayOptions(core, outbound)
+	return core.MakeHttpClient(outbound, maxDelay)
+}
+
+func (c *AutomaticCore) MakeInstance(outbound protocol.Protocol) (protocol.Instance, error) {
+	core, err := c.coreOf(outbound)
+	if err != nil {
+		return nil, err
+	}
+	c.warnXrayOptions(core, outbound)
+	return core.MakeInstance(outbound)
+}
+
+// warnXrayOptions reports a config that runs on sing-box, without the options set on the xray core (e.g. --fragment)
+func (c *AutomaticCore) warnXrayOptions(core Core, p protocol.Protocol) {
+	if c.xrayOptions && core == c.singboxCore {
+		customlog.Printf(customlog.Failure, "%s runs on sing-box, without the --fragment and --noise of xray\n",
+			protocol.RedactLink(p.ConvertToGeneralConfig().OrigLink))
+	}
+}
+
+// SetInbound sets the inbound of both cores, the outbound decides which one runs
+func (c *AutomaticCore) SetInbound(inbound protocol.Protocol) error {
+	if err := c.xrayCore.SetInbound(inbound); err != nil {
+		return err
+	}
+	return c.singboxCore.SetInbound(inbound)
+}
@@ -0,0 +1,156 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/naser-989/xray-knife/v3/pkg/singbox"
+	"github.com/naser-989/xray-knife/v3/pkg/xray"
+)
+
+func TestAutomaticCore_SelectCore(t *testing.T) {
+	c := NewAutomaticCore(false, false)
+	tests := map[string]string{
+		"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443#vless":                                               "xray",
+		"socks://dXNlcjpwYXNz@example.com:1080#socks":                                                                      "xray",
+		"hy2://pw@example.com:443,20000-30000/?sni=example.com#hop":                                                        "singbox",
+		"ss://YWVzLTI1Ni1nY206c2VjcmV0@example.com:443?plugin=obfs-local%3Bobfs%3Dhttp#obfs":                               "singbox",
+		"ss://YWVzLTI1Ni1nY206c2VjcmV0@example.com:443?plugin=v2ray-plugin%3Bmode%3Dwebsocket%3Bhost%3Dcdn.example.com#ws": "xray",
+		"ss://YWVzLTI1Ni1nY206c2VjcmV0@example.com:443?plugin=v2ray-plugin%3Bmode%3Dquic#quic":                             "singbox",
+	}
+	for link, want := range tests {
+		core, err := c.SelectCore(link)
+		if err != nil {
+			t.Errorf("%s: %v", link, err)
+			continue
+		}
+		if core.Name() != want {
+			t.Errorf("%s: got %s, want %s", link, core.Name(), want)
+		}
+	}
+
+	if _, err := c.SelectCore("snell://example.com:443"); protocol.ReasonOf(err) != protocol.ReasonUnknownScheme {
+		t.Errorf("expected an unknown scheme error, got %v", err)
+	}
+}
+
+func TestAutomaticCore_SetRoutes(t *testing.T) {
+	c := NewAutomaticCore(false, false)
+	if err := c.SetRoutes([]string{"socks=singbox"}); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := c.CreateProtocol("socks://dXNlcjpwYXNz@example.com:1080#socks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(singbox.Protocol); !ok {
+		t.Errorf("expected a sing-box protocol, got %T", p)
+	}
+
+	// The registry is not changed
+	if _, core, _ := protocol.Lookup(protocol.SocksIdentifier); core != protocol.CoreXray {
+		t.Error("the override changed the registry")
+	}
+
+	for _, route := range []string{"socks", "socks=clash", "socks=auto"} {
+		if err = c.SetRoutes([]string{route}); err == nil {
+			t.Errorf("%s: expected an error", route)
+		}
+	}
+}
+
+func TestAutomaticCore_Build(t *testing.T) {
+	c := NewAutomaticCore(false, false)
+
+	p, err := c.Build(protocol.NewVless("vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443#vless"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(xray.Protocol); !ok {
+		t.Errorf("expected an xray protocol, got %T", p)
+	}
+
+	// A protocol built by a core stays with it
+	built, err := singbox.Build(protocol.NewVless("vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443#vless"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err = c.Build(built); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(singbox.Protocol); !ok {
+		t.Errorf("expected a sing-box protocol, got %T", p)
+	}
+}
+
+func TestAutomaticCore_SelectCoreByTransport(t *testing.T) {
+	c := NewAutomaticCore(false, false)
+
+	// vless goes to xray, unless only sing-box has the transport
+	core, err := c.SelectCore("vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=quic#quic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if core.Name() != "singbox" {
+		t.Errorf("got %s, want singbox", core.Name())
+	}
+
+	if err = c.SetRoutes([]string{"vless=singbox"}); err != nil {
+		t.Fatal(err)
+	}
+	if core, err = c.SelectCore("vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=xhttp#xhttp"); err != nil {
+		t.Fatal(err)
+	}
+	if core.Name() != "xray" {
+		t.Errorf("got %s, want xray", core.Name())
+	}
+}
+
+func TestAutomaticCore_RegisteredScheme(t *testing.T) {
+	registry := protocol.NewRegistry()
+	registry.Register("corp-hy2", protocol.CoreSingbox, func(link string) protocol.Protocol {
+		return protocol.NewHysteria2(protocol.Hysteria2Identifier + strings.TrimPrefix(link, "corp-hy2"))
+	})
+	registry.Register("corp-vless", protocol.CoreSingbox, func(link string) protocol.Protocol {
+		return protocol.NewVless(protocol.VlessIdentifier + strings.TrimPrefix(link, "corp-vless"))
+	})
+
+	c := NewAutomaticCore(false, false)
+	c.SetRegistry(registry)
+	p, err := c.CreateProtocol("corp-hy2://pw@example.com:443?sni=example.com#corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(singbox.Protocol); !ok {
+		t.Errorf("expected a sing-box protocol, got %T", p)
+	}
+
+	// A protocol made by the alias goes to the core of the alias, not to the one of vless
+	if p, err = registry.CreateProtocol("corp-vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443#corp"); err != nil {
+		t.Fatal(err)
+	}
+	if p, err = c.Build(p); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(singbox.Protocol); !ok {
+		t.Errorf("expected a sing-box protocol, got %T", p)
+	}
+}
+
+func TestSetXrayOptions(t *testing.T) {
+	fragment := xray.WithFragment(xray.Fragment{Packets: "tlshello", Length: "100-200", Interval: "10-20"})
+
+	c := NewAutomaticCore(false, false)
+	if err := SetXrayOptions(c, fragment); err != nil {
+		t.Fatal(err)
+	}
+	if c.xrayCore.(*xray.Core).Fragment == nil || !c.xrayOptions {
+		t.Error("the fragment wasn't set on the xray core")
+	}
+
+	if err := SetXrayOptions(CoreFactory(SingboxCoreType, false, false), fragment); err == nil {
+		t.Error("expected an error for the sing-box core")
+	}
+}
@@ -2,12 +2,12 @@ package pkg
 
 import (
 	"bufio"
-	"errors"
 	"fmt"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/naser-989/xray-knife/v3/pkg/xray"
+	"github.com/naser-989/xray-knife/v3/utils/customlog"
 	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -26,17 +26,12 @@ type Result struct {
 	DownloadSpeed float32           `csv:"download"` // mbps
 	UploadSpeed   float32           `csv:"upload"`   // mbps
 	IpAddrLoc     string            `csv:"location"` // IP address location
+	Core          string            `csv:"core"`     // Core that tested the config (xray, singbox), last to keep the column positions
 }
 
 type Examiner struct {
 	Core Core
 
-	// Related to automatic core //
-	SelectedCore map[string]Core
-	xrayCore     Core
-	singboxCore  Core
-	// =========================== //
-
 	// Maximum allowed delay (in ms)
 	MaxDelay    uint16
 	Verbose     bool
@@ -71,6 +66,9 @@ type Options struct {
 	TestEndpoint           string
 	TestEndpointHttpMethod string
 	SpeedtestKbAmount      uint32
+
+	// Options of the xray core (e.g. xray.WithFragment), see SetXrayOptions
+	XrayOptions []xray.ServiceOption
 }
 
 func NewExaminer(opts Options) (*Examiner, error) {
@@ -89,29 +87,18 @@ func NewExaminer(opts Options) (*Examiner, error) {
 	if opts.CoreInstance != nil {
 		e.Core = opts.CoreInstance
 	} else {
-		switch opts.Core {
-		case "xray":
-			e.Core = CoreFactory(XrayCoreType, e.InsecureTLS, e.Verbose)
-			break
-		case "singbox":
-			e.Core = CoreFactory(SingboxCoreType, e.InsecureTLS, e.Verbose)
-			break
-		default:
-			e.Core = nil
-			e.xrayCore = CoreFactory(XrayCoreType, e.InsecureTLS, e.Verbose)
-			e.singboxCore = CoreFactory(SingboxCoreType, e.InsecureTLS, e.Verbose)
-			e.SelectedCore = map[string]Core{
-				protocol.VmessIdentifier:       e.xrayCore,
-				protocol.VlessIdentifier:       e.xrayCore,
-				protocol.ShadowsocksIdentifier: e.xrayCore,
-				protocol.TrojanIdentifier:      e.xrayCore,
-				protocol.SocksIdentifier:       e.xrayCore,
-				protocol.WireguardIdentifier:   e.xrayCore,
-				protocol.Hysteria2Identifier:   e.singboxCore,
-				"hy2":                          e.singboxCore,
+		coreType := AutoCoreType
+		if opts.Core != "" {
+			var err error
+			if coreType, err = ParseCoreType(opts.Core); err != nil {
+				return nil, err
 			}
-			break
 		}
+		e.Core = CoreFactory(coreType, e.InsecureTLS, e.Verbose)
+	}
+
+	if err := SetXrayOptions(e.Core, opts.XrayOptions...); err != nil {
+		return nil, err
 	}
 
 	if opts.MaxDelay != 0 {
@@ -151,45 +138,72 @@ func (e *Examiner) ExamineConfig(link string) (Result, error) {
 	// Remove any spaces from the link
 	link = strings.TrimSpace(link)
 
-	var core = e.Core
-
-	// Select core based on config (Automatic Core)
-	if core == nil {
-		uri, err := url.Parse(link)
-		if err != nil {
-			return Result{}, errors.New(fmt.Sprintf("Couldn't parse the config: %v", err))
+	// The automatic core falls back to the other core when the routed one can't build the config:
+	// the config is parsed once from the registry of the automatic core, then built on each core
+	var err error
+	var parsed protocol.Protocol
+	cores := []Core{e.Core}
+	auto, _ := e.Core.(*AutomaticCore)
+	if auto != nil {
+		if cores, err = auto.Candidates(link); err == nil {
+			parsed, err = auto.registry.CreateProtocol(link)
 		}
-
-		coreAuto, ok := e.SelectedCore[uri.Scheme]
-		if !ok {
-			return Result{}, errors.New(fmt.Sprintf("Couldn't parse the config: invalid protocol"))
+		if err == nil {
+			err = parsed.Parse()
+		}
+		if err != nil {
+			return r, fmt.Errorf("Couldn't parse the config: %w", err)
 		}
-
-		core = coreAuto
 	}
 
-	proto, err := core.CreateProtocol(link)
-	if err != nil {
-		return r, errors.New(fmt.Sprintf("Couldn't parse the config: %v", err))
-		//os.Exit(1)
-	}
+	var client *http.Client
+	var instance protocol.Instance
+	var reasons []string
+	for i, core := range cores {
+		var proto protocol.Protocol
+		if parsed != nil {
+			proto, err = core.Build(parsed)
+		} else if proto, err = core.CreateProtocol(link); err == nil {
+			err = proto.Parse()
+		}
+		if err != nil {
+			if i == 0 {
+				return r, fmt.Errorf("Couldn't parse the config: %w", err)
+			}
+			// The fallback core doesn't support the protocol
+			continue
+		}
 
-	err = proto.Parse()
-	if err != nil {
-		return r, errors.New(fmt.Sprintf("Couldn't parse the config: %v", err))
-	}
+		if i == 0 {
+			if e.Verbose {
+				fmt.Printf("%v\n", proto.DetailsStr())
+			}
+			r.Protocol = proto
+			r.TLS = proto.ConvertToGeneralConfig().TLS
+			r.Core = core.Name()
+		}
 
-	if e.Verbose {
-		fmt.Printf("%v\n", proto.DetailsStr())
+		if auto != nil {
+			auto.warnXrayOptions(core, proto)
+		}
+		client, instance, err = core.MakeHttpClient(proto, time.Duration(e.MaxDelay)*time.Millisecond)
+		if err == nil {
+			r.Protocol = proto
+			r.Core = core.Name()
+			break
+		}
+		if len(cores) == 1 {
+			reasons = append(reasons, err.Error())
+		} else {
+			reasons = append(reasons, fmt.Sprintf("%s: %v", core.Name(), err))
+		}
+		if e.Verbose && i+1 < len(cores) {
+			customlog.Printf(customlog.Processing, "%s couldn't build the config, trying %s\n", core.Name(), cores[i+1].Name())
+		}
 	}
-
-	r.Protocol = proto
-	r.TLS = proto.ConvertToGeneralConfig().TLS
-
-	client, instance, err := core.MakeHttpClient(proto, time.Duration(e.MaxDelay)*time.Millisecond)
-	if err != nil {
+	if client == nil {
 		r.Status = "broken"
-		r.Reason = err.Error()
+		r.Reason = strings.Join(reasons, "; ")
 		return r, nil
 	}
 	// Close xray conn after testing
@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+)
+
+func TestExaminer_CoreFallback(t *testing.T) {
+	// aes-128-ctr is a stream cipher that sing-box still supports and xray dropped.
+	// Nothing listens on the server, the test only checks which core built the config.
+	link := "ss://YWVzLTEyOC1jdHI6c2VjcmV0@127.0.0.1:9#ctr"
+
+	e, err := NewExaminer(Options{Core: "auto", MaxDelay: 500, TestEndpoint: "http://127.0.0.1:9/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := e.ExamineConfig(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Status == "broken" || r.Core != "singbox" {
+		t.Errorf("expected the sing-box fallback, got status %s on %s core: %s", r.Status, r.Core, r.Reason)
+	}
+
+	e, err = NewExaminer(Options{Core: "xray", MaxDelay: 500, TestEndpoint: "http://127.0.0.1:9/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err = e.ExamineConfig(link); err != nil {
+		t.Fatal(err)
+	}
+	if r.Status != "broken" || r.Core != "xray" {
+		t.Errorf("expected a broken config on xray without fallback, got status %s on %s core", r.Status, r.Core)
+	}
+}
+
+func TestExaminer_RegisteredScheme(t *testing.T) {
+	registry := protocol.NewRegistry()
+	registry.Register("corp-ss", protocol.CoreSingbox, func(link string) protocol.Protocol {
+		return protocol.NewShadowsocks(protocol.ShadowsocksIdentifier + strings.TrimPrefix(link, "corp-ss"))
+	})
+	core := NewAutomaticCore(false, false)
+	core.SetRegistry(registry)
+
+	// Nothing listens on the server, the test only checks that the scheme of the registry is tested
+	e, err := NewExaminer(Options{CoreInstance: core, MaxDelay: 500, TestEndpoint: "http://127.0.0.1:9/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := e.ExamineConfig("corp-ss://YWVzLTI1Ni1nY206c2VjcmV0@127.0.0.1:9#corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Status == "broken" || r.Core != "singbox" {
+		t.Errorf("expected the sing-box core of the alias, got status %s on %s core: %s", r.Status, r.Core, r.Reason)
+	}
+}
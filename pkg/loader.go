@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/naser-989/xray-knife/v3/pkg/clash"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/naser-989/xray-knife/v3/pkg/singbox"
+	"github.com/naser-989/xray-knife/v3/pkg/wgquick"
+	"github.com/naser-989/xray-knife/v3/pkg/xray"
+	"github.com/naser-989/xray-knife/v3/utils"
+	"github.com/naser-989/xray-knife/v3/utils/customlog"
+	xjson "github.com/xtls/xray-core/infra/conf/json"
+)
+
+// ReadConfigLinks reads config links from a file.
+// Besides newline separated share links, it accepts full xray-core and sing-box JSON configs,
+// Clash YAML configs and wg-quick configs, in which case every outbound/proxy/peer is converted into its share link.
+func ReadConfigLinks(fileName string) ([]string, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if clash.IsConfig(data) {
+		return ClashLinks(data)
+	}
+	if wgquick.IsConfig(data) {
+		// wg-quick names the interface after the file, so does the remark
+		name := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+		return protocolLinks(wgquick.LoadPeers(data, name))
+	}
+	if !isJSON(fileName, data) {
+		return utils.ParseFileByNewline(fileName), nil
+	}
+
+	var protocols []protocol.Protocol
+	var errs []error
+	if isSingboxConfig(data) {
+		outbounds, loadErrs := singbox.LoadOutbounds(data)
+		for _, p := range outbounds {
+			protocols = append(protocols, p)
+		}
+		errs = loadErrs
+	} else {
+		outbounds, loadErrs := xray.LoadOutbounds(data)
+		for _, p := range outbounds {
+			protocols = append(protocols, p)
+		}
+		errs = loadErrs
+	}
+
+	return protocolLinks(protocols, errs)
+}
+
+// ClashLinks converts the proxies of a Clash / Mihomo YAML config into share links
+func ClashLinks(data []byte) ([]string, error) {
+	return protocolLinks(clash.LoadProxies(data))
+}
+
+// protocolLinks turns the loaded protocols into share links and reports the skipped entries.
+// It only fails when nothing could be loaded.
+func protocolLinks(protocols []protocol.Protocol, errs []error) ([]string, error) {
+	if len(protocols) == 0 && len(errs) != 0 {
+		return nil, errs[0]
+	}
+	for _, err := range errs {
+		customlog.Printf(customlog.Failure, "Skipped %v\n", err)
+	}
+
+	var links []string
+	for _, p := range protocols {
+		links = append(links, p.ToLink())
+	}
+	return links, nil
+}
+
+func isJSON(fileName string, data []byte) bool {
+	return filepath.Ext(fileName) == ".json" || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// isSingboxConfig tells sing-box configs apart from xray ones:
+// sing-box outbounds are keyed by "type" while xray uses "protocol"
+func isSingboxConfig(data []byte) bool {
+	var config struct {
+		Outbounds []struct {
+			Type     string `json:"type"`
+			Protocol string `json:"protocol"`
+		} `json:"outbounds"`
+	}
+	// Both cores allow comments in their config files
+	if err := json.NewDecoder(&xjson.Reader{Reader: bytes.NewReader(data)}).Decode(&config); err != nil {
+		return false
+	}
+
+	for _, out := range config.Outbounds {
+		if out.Protocol != "" {
+			return false
+		}
+		if out.Type != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package protocol
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Capabilities lists the schemes, transports and security layers a core supports
+type Capabilities struct {
+	Core       string   `json:"core"`
+	Schemes    []string `json:"schemes"`
+	Transports []string `json:"transports"` // Networks of the V2Ray style links (vmess, vless, trojan)
+	Security   []string `json:"security"`
+}
+
+// UnsupportedError is the error of Capabilities.Check
+type UnsupportedError struct {
+	Core  string
+	Kind  string // scheme, transport or security
+	Field string // Link parameter at fault (e.g. "type"), empty for the scheme
+	Value string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s core doesn't support the %s %s", e.Core, e.Value, e.Kind)
+}
+
+// Aliases of the transports, checked under their canonical name
+var transportAliases = map[string]string{
+	"":     "tcp",
+	"raw":  "tcp",
+	"mkcp": "kcp",
+	"h2":   "http",
+}
+
+// SchemeOf returns the link scheme of a protocol, from its link when it has one
+func SchemeOf(p Protocol) string {
+	g := p.ConvertToGeneralConfig()
+	if g.OrigLink != "" {
+		link, _ := SplitHopPorts(g.OrigLink)
+		if uri, err := url.Parse(link); err == nil && uri.Scheme != "" {
+			return uri.Scheme
+		}
+	}
+	if g.Protocol == "shadowsocks" {
+		return ShadowsocksIdentifier
+	}
+	return g.Protocol
+}
+
+// Merge returns the union of two capabilities
+func (c Capabilities) Merge(other Capabilities) Capabilities {
+	union := func(a, b []string) []string {
+		merged := append([]string{}, a...)
+		for _, v := range b {
+			if !contains(merged, v) {
+				merged = append(merged, v)
+			}
+		}
+		return merged
+	}
+	return Capabilities{
+		Core:       c.Core + "+" + other.Core,
+		Schemes:    union(c.Schemes, other.Schemes),
+		Transports: union(c.Transports, other.Transports),
+		Security:   union(c.Security, other.Security),
+	}
+}
+
+// Check reports the first part of a parsed config the core doesn't support
+func (c Capabilities) Check(p Protocol) error {
+	// The scheme of the protocol, not of the link: registered aliases are what they stand for
+	g := p.ConvertToGeneralConfig()
+	scheme := g.Protocol
+	if scheme == "shadowsocks" {
+		scheme = ShadowsocksIdentifier
+	}
+	if !contains(c.Schemes, scheme) {
+		return &UnsupportedError{Core: c.Core, Kind: "scheme", Value: scheme}
+	}
+
+	networkField, securityField := "type", "security"
+	switch g.Protocol {
+	case "vmess":
+		networkField, securityField = "net", "tls"
+	case "vless", "trojan":
+	default:
+		// The other protocols have a fixed transport and security
+		return nil
+	}
+
+	network := g.Network
+	if alias, ok := transportAliases[network]; ok {
+		network = alias
+	}
+	if !contains(c.Transports, network) {
+		return &UnsupportedError{Core: c.Core, Kind: "transport", Field: networkField, Value: network}
+	}
+
+	security := g.TLS
+	switch security {
+	case "":
+		security = "none"
+	case "xtls":
+		security = "tls"
+	}
+	if !contains(c.Security, security) {
+		return &UnsupportedError{Core: c.Core, Kind: "security", Field: securityField, Value: security}
+	}
+	return nil
+}
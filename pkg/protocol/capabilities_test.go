@@ -0,0 +1,53 @@
+package protocol
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCapabilities_Check(t *testing.T) {
+	c := Capabilities{
+		Core:       "test",
+		Schemes:    []string{VmessIdentifier, VlessIdentifier},
+		Transports: []string{"tcp", "ws"},
+		Security:   []string{"none", "tls"},
+	}
+
+	tests := map[string]string{ // link: kind of the error, empty when supported
+		"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=ws&security=tls#ws":          "",
+		"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=raw#raw":                     "",
+		"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=grpc#grpc":                   "transport",
+		"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?security=reality&pbk=key#reality": "security",
+		"trojan://secret@example.com:443#trojan":                                                        "scheme",
+	}
+	for link, kind := range tests {
+		p, err := CreateProtocol(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = p.Parse(); err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.Check(p)
+		var unsupported *UnsupportedError
+		switch {
+		case kind == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", link, err)
+		case kind != "" && !errors.As(err, &unsupported):
+			t.Errorf("%s: expected an unsupported %s, got %v", link, kind, err)
+		case kind != "" && unsupported.Kind != kind:
+			t.Errorf("%s: got unsupported %s, want %s", link, unsupported.Kind, kind)
+		}
+	}
+}
+
+func TestCapabilities_Merge(t *testing.T) {
+	a := Capabilities{Core: "a", Schemes: []string{"vless"}, Transports: []string{"tcp", "ws"}}
+	b := Capabilities{Core: "b", Schemes: []string{"vless", "tuic"}, Transports: []string{"quic"}}
+
+	m := a.Merge(b)
+	if len(m.Schemes) != 2 || len(m.Transports) != 3 {
+		t.Errorf("unexpected union %+v", m)
+	}
+}
@@ -0,0 +1,94 @@
+package protocol
+
+import (
+	"strconv"
+	"strings"
+)
+
+// credential returns what authenticates the client, two links of the same server
+// with different credentials are different configs
+func credential(p Protocol) string {
+	switch v := p.(type) {
+	case *Vmess:
+		return v.ID
+	case *Vless:
+		return v.ID
+	case *Trojan:
+		return v.Password
+	case *Shadowsocks:
+		return v.Encryption + ":" + v.Password
+	case *Socks:
+		return v.Username + ":" + v.Password
+	case *Http:
+		return v.Username + ":" + v.Password
+	case *Wireguard:
+		return v.SecretKey + ":" + v.PublicKey
+	case *Hysteria:
+		return v.Auth
+	case *Hysteria2:
+		return v.Password
+	case *Tuic:
+		return v.UUID + ":" + v.Password
+	}
+	return ""
+}
+
+// IdentityKey returns the canonical identity of a parsed config: protocol, address, port, credential,
+// transport, path/host, security and SNI. Links that only differ by their remark, parameter order
+// or vmess JSON key order have the same key.
+func IdentityKey(p Protocol) string {
+	g := p.ConvertToGeneralConfig()
+
+	var host, path string
+	if g.Network != "" {
+		host, path = strings.ToLower(g.Host), g.Path
+		if g.ServiceName != "" {
+			path = g.ServiceName
+		}
+	}
+	security := securityOf(g)
+	var sni string
+	if security == SecurityTLS || security == SecurityReality {
+		sni = strings.ToLower(g.SNI)
+	}
+	port, _ := strconv.Atoi(g.Port)
+
+	return strings.Join([]string{
+		g.Protocol,
+		strings.ToLower(strings.Trim(g.Address, "[]")),
+		strconv.Itoa(port),
+		credential(p),
+		g.Network,
+		host,
+		path,
+		string(security),
+		sni,
+	}, "|")
+}
+
+// Dedupe removes the links that are the same config as a previous one, see IdentityKey.
+// The first link of each config is kept; links that can't be parsed are only compared as strings.
+// seen holds the keys of the links kept so far, pass the same map to dedupe several sources together.
+// It returns the kept links and the number of collapsed duplicates.
+func Dedupe(links []string, seen map[string]bool) ([]string, int) {
+	var kept []string
+	duplicates := 0
+	for _, link := range links {
+		link = strings.TrimSpace(link)
+		if link == "" {
+			continue
+		}
+
+		key := "link|" + link
+		if p, err := CreateProtocol(link); err == nil && p.Parse() == nil {
+			key = IdentityKey(p)
+		}
+		if seen[key] {
+			duplicates++
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, link)
+	}
+	return kept, duplicates
+}
@@ -0,0 +1,33 @@
+package protocol
+
+import "testing"
+
+func TestDedupe(t *testing.T) {
+	links := []string{
+		"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=ws&security=tls&path=%2Fws&host=cdn.example.com&sni=example.com#first",
+		// Same server, other remark and parameter order
+		"vless://0090bbba-1118-46ca-87a1-52599cee74ab@Example.com:443?sni=example.com&host=cdn.example.com&security=tls&path=%2Fws&type=ws#second",
+		// Other path
+		"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=ws&security=tls&path=%2Fother&host=cdn.example.com&sni=example.com#path",
+		// vmess with the JSON keys in another order
+		"vmess://eyJ2IjoiMiIsInBzIjoiYSIsImFkZCI6IjEuMS4xLjEiLCJwb3J0IjoiNDQzIiwiaWQiOiI3MmQ3MzliNC0xNjdjLTQwZWUtYTljNC0wNmRiNzRlNGJmMzkiLCJuZXQiOiJ3cyIsInBhdGgiOiIvd3MiLCJ0bHMiOiJ0bHMifQ==",
+		"vmess://eyJhZGQiOiIxLjEuMS4xIiwiaWQiOiI3MmQ3MzliNC0xNjdjLTQwZWUtYTljNC0wNmRiNzRlNGJmMzkiLCJuZXQiOiJ3cyIsInBhdGgiOiIvd3MiLCJwb3J0IjoiNDQzIiwicHMiOiJiIiwidGxzIjoidGxzIiwidiI6IjIifQ==",
+		// Other password
+		"ss://YWVzLTI1Ni1nY206c2VjcmV0@example.com:8388#ss",
+		"ss://YWVzLTI1Ni1nY206b3RoZXI@example.com:8388#ss",
+		"not a link",
+		"not a link",
+	}
+
+	kept, duplicates := Dedupe(links, map[string]bool{})
+	if duplicates != 3 || len(kept) != 6 {
+		t.Errorf("expected 3 duplicates and 6 links, got %d and %v", duplicates, kept)
+	}
+
+	// Links already seen in another source are duplicates too
+	seen := map[string]bool{}
+	Dedupe(links[:1], seen)
+	if kept, duplicates = Dedupe(links[1:2], seen); duplicates != 1 || len(kept) != 0 {
+		t.Errorf("expected the link of the first source to be collapsed, got %v", kept)
+	}
+}
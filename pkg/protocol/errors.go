@@ -0,0 +1,157 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Reason is the kind of a ParseError
+type Reason string
+
+const (
+	ReasonMalformedLink     Reason = "malformed link"     // The link is not a valid URL
+	ReasonUnknownScheme     Reason = "unknown scheme"     // No protocol has this scheme
+	ReasonBadBase64         Reason = "bad base64"         // A base64 encoded part can't be decoded
+	ReasonBadJSON           Reason = "bad json"           // The decoded vmess JSON can't be unmarshalled
+	ReasonBadCredential     Reason = "bad credential"     // The credential is not in the expected form (e.g. method:password)
+	ReasonMissingCredential Reason = "missing credential" // The link has no user id, password or key
+	ReasonMissingHost       Reason = "missing host"
+	ReasonMissingPort       Reason = "missing port"
+	ReasonBadAddress        Reason = "bad address" // The host:port part can't be split
+	ReasonBadValue          Reason = "bad value"   // A link parameter has a value of the wrong type
+	ReasonUnknownTransport  Reason = "unknown transport"
+	ReasonOther             Reason = "other" // Not a ParseError
+)
+
+// ParseError is the error returned by the Parse methods and CreateProtocol
+type ParseError struct {
+	Scheme string // Scheme of the link, empty when the link is not a valid URL
+	Field  string // Part of the link or parameter the error is about (e.g. "address", "type"), may be empty
+	Value  string // Offending value, may be empty
+	Reason Reason
+	Err    error // Underlying error, may be nil
+}
+
+func (e *ParseError) Error() string {
+	var b strings.Builder
+	if e.Scheme != "" {
+		b.WriteString(e.Scheme + ": ")
+	}
+	b.WriteString(string(e.Reason))
+	if e.Field != "" {
+		b.WriteString(": " + e.Field)
+	}
+	if e.Value != "" {
+		// The value may be a secret (e.g. a base64 encoded vmess config)
+		fmt.Fprintf(&b, " %q", Redact(e.Value))
+	}
+	if e.Err != nil {
+		b.WriteString(": " + e.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ReasonOf returns the reason of a ParseError, ReasonOther for the other errors
+func ReasonOf(err error) Reason {
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		return pe.Reason
+	}
+	return ReasonOther
+}
+
+// parseURL parses a link like url.Parse, with typed errors that don't repeat the link
+func parseURL(scheme string, link string) (*url.URL, error) {
+	uri, err := url.Parse(link)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, &ParseError{Scheme: scheme, Reason: ReasonMalformedLink, Err: err}
+	}
+	return uri, nil
+}
+
+// checkScheme reports a link that doesn't belong to the protocol, whose links use scheme or one of its aliases
+func checkScheme(scheme string, link string, aliases ...string) error {
+	for _, s := range append([]string{scheme}, aliases...) {
+		if strings.HasPrefix(strings.ToLower(link), s+"://") {
+			return nil
+		}
+	}
+	linkScheme, _, _ := strings.Cut(link, "://")
+	return &ParseError{Scheme: scheme, Field: "scheme", Value: linkScheme, Reason: ReasonUnknownScheme}
+}
+
+// splitHostPort splits the host:port part of a link like net.SplitHostPort, with typed errors
+func splitHostPort(scheme string, hostport string) (host, port string, err error) {
+	if hostport == "" {
+		return "", "", &ParseError{Scheme: scheme, Field: "address", Reason: ReasonMissingHost}
+	}
+	host, port, err = net.SplitHostPort(hostport)
+	if err != nil {
+		var addrErr *net.AddrError
+		if errors.As(err, &addrErr) && addrErr.Err == "missing port in address" {
+			return "", "", &ParseError{Scheme: scheme, Field: "address", Value: hostport, Reason: ReasonMissingPort}
+		}
+		return "", "", &ParseError{Scheme: scheme, Field: "address", Value: hostport, Reason: ReasonBadAddress, Err: err}
+	}
+	if port == "" {
+		return "", "", &ParseError{Scheme: scheme, Field: "address", Value: hostport, Reason: ReasonMissingPort}
+	}
+	return host, port, nil
+}
+
+// checkNetwork reports an unknown transport of a V2Ray style link, field is the link parameter
+func checkNetwork(scheme string, field string, network string) error {
+	if network != "" && !contains(networks, network) {
+		return &ParseError{Scheme: scheme, Field: field, Value: network, Reason: ReasonUnknownTransport}
+	}
+	return nil
+}
+
+// ParseFailures counts parse errors by reason
+type ParseFailures map[Reason]int
+
+// Add counts an error
+func (f ParseFailures) Add(err error) {
+	f[ReasonOf(err)]++
+}
+
+// Total returns the number of counted errors
+func (f ParseFailures) Total() int {
+	total := 0
+	for _, n := range f {
+		total += n
+	}
+	return total
+}
+
+// String lists the counts, the most frequent reason first (e.g. "3 bad base64, 1 missing port")
+func (f ParseFailures) String() string {
+	reasons := make([]Reason, 0, len(f))
+	for reason := range f {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if f[reasons[i]] != f[reasons[j]] {
+			return f[reasons[i]] > f[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+
+	parts := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		parts = append(parts, fmt.Sprintf("%d %s", f[reason], reason))
+	}
+	return strings.Join(parts, ", ")
+}
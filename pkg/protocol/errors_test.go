@@ -0,0 +1,77 @@
+package protocol
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseErrorReasons(t *testing.T) {
+	tests := map[string]Reason{
+		"snell://example.com:443":                        ReasonUnknownScheme,
+		"vless://id@exa mple.com:443#bad":                ReasonMalformedLink,
+		"vmess://not-base64!!":                           ReasonBadBase64,
+		"vmess://eyJhZGQiOjF9":                           ReasonBadJSON, // {"add":1}
+		"ss://!!!@example.com:443":                       ReasonBadBase64,
+		"ss://YWVzLTI1Ni1nY20@example.com:443":           ReasonBadCredential, // aes-256-gcm
+		"ss://example.com:443":                           ReasonMissingCredential,
+		"trojan://pw@example.com#no-port":                ReasonMissingPort,
+		"vless://id@example.com:443?type=carrier-pigeon": ReasonUnknownTransport,
+		"tuic://example.com:443":                         ReasonMissingCredential,
+		"hysteria2://pw@/?sni=example.com":               ReasonMissingHost,
+		"wireguard://key@example.com:51820?mtu=big":      ReasonBadValue,
+	}
+	for link, want := range tests {
+		p, err := CreateProtocol(link)
+		if err == nil {
+			err = p.Parse()
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", link)
+			continue
+		}
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s: %T is not a ParseError: %v", link, err, err)
+			continue
+		}
+		if pe.Reason != want {
+			t.Errorf("%s: got reason %q (%v), want %q", link, pe.Reason, err, want)
+		}
+	}
+}
+
+func TestParseFailures(t *testing.T) {
+	failures := make(ParseFailures)
+	failures.Add(&ParseError{Reason: ReasonMissingPort})
+	failures.Add(&ParseError{Reason: ReasonBadBase64})
+	failures.Add(errors.New("something else"))
+	failures.Add(&ParseError{Reason: ReasonBadBase64})
+
+	if got := failures.Total(); got != 4 {
+		t.Errorf("got total %d, want 4", got)
+	}
+	if got, want := failures.String(), "2 bad base64, 1 missing port, 1 other"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseErrorScheme(t *testing.T) {
+	for _, p := range []Protocol{
+		NewTuic("vless://uuid@example.com:443"),
+		NewHysteria("hysteria2://pw@example.com:443"),
+		NewHysteria2("hysteria://example.com:443?auth=pw"),
+		NewHttp("socks://example.com:1080"),
+		NewVless("vmess://eyJhZGQiOjF9"),
+	} {
+		if err := p.Parse(); ReasonOf(err) != ReasonUnknownScheme {
+			t.Errorf("%T: got %v, want an unknown scheme error", p, err)
+		}
+	}
+
+	// The aliases of a scheme are its own
+	for _, p := range []Protocol{NewHysteria2("hy2://pw@example.com:443"), NewHttp("https://example.com:443")} {
+		if err := p.Parse(); err != nil {
+			t.Errorf("%T: %v", p, err)
+		}
+	}
+}
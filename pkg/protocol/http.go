@@ -0,0 +1,174 @@
+package protocol
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/naser-989/xray-knife/v3/utils"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+func NewHttp(link string) *Http {
+	return &Http{OrigLink: link}
+}
+
+func (h *Http) Name() string {
+	return HttpIdentifier
+}
+
+func (h *Http) Parse() error {
+	if err := checkScheme(HttpIdentifier, h.OrigLink, HttpsIdentifier); err != nil {
+		return err
+	}
+	uri, err := parseURL(HttpIdentifier, h.OrigLink)
+	if err != nil {
+		return err
+	}
+
+	if uri.Scheme == HttpsIdentifier {
+		h.TLS = "tls"
+	}
+
+	h.Address = uri.Hostname()
+	if h.Address == "" {
+		return &ParseError{Scheme: uri.Scheme, Field: "address", Reason: ReasonMissingHost}
+	}
+	h.Port = uri.Port()
+	if h.Port == "" {
+		h.Port = "80"
+		if h.TLS == "tls" {
+			h.Port = "443"
+		}
+	}
+
+	if uri.User != nil {
+		h.Username = uri.User.Username()
+		h.Password, _ = uri.User.Password()
+
+		// Some clients share the credentials base64 encoded, like socks links
+		if _, ok := uri.User.Password(); !ok {
+			if creds, err := utils.Base64Decode(h.Username); err == nil && strings.Contains(string(creds), ":") {
+				h.Username, h.Password, _ = strings.Cut(string(creds), ":")
+			}
+		}
+	}
+
+	// Get the type of the struct
+	t := reflect.TypeOf(*h)
+
+	// Get the number of fields in the struct
+	numFields := t.NumField()
+
+	// Iterate over each field of the struct
+	for i := 0; i < numFields; i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		// If the query value exists for the field, set it
+		if values, ok := uri.Query()[tag]; ok {
+			v := reflect.ValueOf(h).Elem().FieldByName(field.Name)
+			if v.Kind() == reflect.String {
+				v.SetString(values[0])
+			}
+		}
+	}
+
+	// Some clients write "insecure" instead of "allowInsecure"
+	if h.AllowInsecure == "" {
+		h.AllowInsecure = uri.Query().Get("insecure")
+	}
+
+	h.Remark, err = url.PathUnescape(uri.Fragment)
+	if err != nil {
+		h.Remark = uri.Fragment
+	}
+
+	return nil
+}
+
+func (h *Http) Validate() Diagnostics {
+	var d Diagnostics
+	d.CheckAddress("address", h.Address)
+	d.CheckPort("port", h.Port)
+	if h.Username == "" && h.Password != "" {
+		d.Warnf("username", "password without username, it won't be sent")
+	}
+	if h.TLS == "tls" {
+		d.CheckFingerprint("fp", h.TlsFingerprint)
+	}
+
+	return d
+}
+
+func (h *Http) DetailsStr() string {
+	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %s\n",
+		color.RedString("Protocol"), h.Name(),
+		color.RedString("Remark"), h.Remark,
+		color.RedString("Network"), "tcp",
+		color.RedString("Address"), h.Address,
+		color.RedString("Port"), h.Port,
+	)
+
+	if len(h.Username) != 0 {
+		info += fmt.Sprintf("%s: %s\n%s: %s\n",
+			color.RedString("Username"), Redact(h.Username),
+			color.RedString("Password"), Redact(h.Password))
+	}
+
+	if h.TLS == "tls" {
+		info += fmt.Sprintf("%s: %s\n", color.RedString("TLS"), h.TLS)
+		if len(h.SNI) == 0 {
+			info += fmt.Sprintf("%s: %v\n", color.RedString("SNI"), "none")
+		} else {
+			info += fmt.Sprintf("%s: %v\n", color.RedString("SNI"), h.SNI)
+		}
+		if h.TlsFingerprint != "" {
+			info += fmt.Sprintf("%s: %v\n", color.RedString("Fingerprint"), h.TlsFingerprint)
+		}
+		if h.AllowInsecure != "" {
+			info += fmt.Sprintf("%s: %v\n", color.RedString("Insecure"), h.AllowInsecure)
+		}
+	}
+
+	return info
+}
+
+func (h *Http) ConvertToGeneralConfig() (g GeneralConfig) {
+	g.Protocol = h.Name()
+	g.Address = h.Address
+	g.Port = h.Port
+	g.Remark = h.Remark
+	g.Network = "tcp"
+	g.TLS = h.TLS
+	g.SNI = h.SNI
+	g.TlsFingerprint = h.TlsFingerprint
+	g.AllowInsecure = h.AllowInsecure
+
+	g.OrigLink = h.OrigLink
+
+	return g
+}
+
+func (h *Http) ToLink() string {
+	scheme := HttpIdentifier
+	var query url.Values
+	if h.TLS == "tls" {
+		scheme = HttpsIdentifier
+		query = BuildQuery(h)
+	}
+
+	link := scheme + "://"
+	if h.Username != "" || h.Password != "" {
+		link += url.UserPassword(h.Username, h.Password).String() + "@"
+	}
+	link += JoinHostPort(h.Address, h.Port)
+	if len(query) != 0 {
+		link += "?" + query.Encode()
+	}
+	if h.Remark != "" {
+		link += "#" + url.PathEscape(h.Remark)
+	}
+
+	return link
+}
@@ -0,0 +1,175 @@
+package protocol
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"net/url"
+	"reflect"
+)
+
+func NewHysteria(link string) *Hysteria {
+	return &Hysteria{OrigLink: link}
+}
+
+func (h *Hysteria) Name() string {
+	return HysteriaIdentifier
+}
+
+func (h *Hysteria) Parse() error {
+	if err := checkScheme(HysteriaIdentifier, h.OrigLink); err != nil {
+		return err
+	}
+	uri, err := parseURL(HysteriaIdentifier, h.OrigLink)
+	if err != nil {
+		return err
+	}
+
+	h.Address, h.Port, err = splitHostPort(HysteriaIdentifier, uri.Host)
+	if err != nil {
+		return err
+	}
+
+	// Get the type of the struct
+	t := reflect.TypeOf(*h)
+
+	// Get the number of fields in the struct
+	numFields := t.NumField()
+
+	// Iterate over each field of the struct
+	for i := 0; i < numFields; i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		// If the query value exists for the field, set it
+		if values, ok := uri.Query()[tag]; ok {
+			value := values[0]
+			v := reflect.ValueOf(h).Elem().FieldByName(field.Name)
+
+			switch v.Type().String() {
+			case "string":
+				v.SetString(value)
+			case "int":
+				var intValue int
+				fmt.Sscanf(value, "%d", &intValue)
+				v.SetInt(int64(intValue))
+			}
+		}
+	}
+
+	// Parameter names used by other clients (sing-box style and older links)
+	aliases := []struct {
+		field *string
+		names []string
+	}{
+		{&h.Auth, []string{"auth_str", "auth-str"}},
+		{&h.SNI, []string{"sni"}},
+		{&h.UpMbps, []string{"up_mbps", "up"}},
+		{&h.DownMbps, []string{"down_mbps", "down"}},
+	}
+	for _, alias := range aliases {
+		for _, name := range alias.names {
+			if *alias.field == "" {
+				*alias.field = uri.Query().Get(name)
+			}
+		}
+	}
+
+	h.Remark, err = url.PathUnescape(uri.Fragment)
+	if err != nil {
+		h.Remark = uri.Fragment
+	}
+
+	return nil
+}
+
+func (h *Hysteria) Validate() Diagnostics {
+	var d Diagnostics
+	d.CheckAddress("address", h.Address)
+	d.CheckPort("port", h.Port)
+
+	switch h.Protocol {
+	case "", "udp":
+	case "faketcp", "wechat-video":
+		d.Errorf("protocol", "hysteria %s protocol is not supported by sing-box", h.Protocol)
+	default:
+		d.Errorf("protocol", "unknown hysteria protocol %q", h.Protocol)
+	}
+
+	if h.Obfs == "xplus" && h.ObfsParam == "" {
+		d.Errorf("obfsParam", "xplus obfuscation needs a password")
+	}
+	if _, err := ParseMbps(h.UpMbps); h.UpMbps != "" && err != nil {
+		d.Errorf("upmbps", "%v", err)
+	}
+	if _, err := ParseMbps(h.DownMbps); h.DownMbps != "" && err != nil {
+		d.Errorf("downmbps", "%v", err)
+	}
+
+	return d
+}
+
+func (h *Hysteria) DetailsStr() string {
+	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %s\n",
+		color.RedString("Protocol"), h.Name(),
+		color.RedString("Remark"), h.Remark,
+		color.RedString("Address"), h.Address,
+		color.RedString("Port"), h.Port,
+		color.RedString("Auth"), Redact(h.Auth),
+		color.RedString("Up (Mbps)"), h.UpMbps,
+		color.RedString("Down (Mbps)"), h.DownMbps)
+
+	if h.Protocol != "" {
+		info += fmt.Sprintf("%s: %s\n", color.RedString("Transport Protocol"), h.Protocol)
+	}
+
+	if len(h.SNI) == 0 {
+		info += fmt.Sprintf("%s: %v\n", color.RedString("SNI"), "none")
+	} else {
+		info += fmt.Sprintf("%s: %v\n", color.RedString("SNI"), h.SNI)
+	}
+
+	if len(h.ALPN) != 0 {
+		info += fmt.Sprintf("%s: %v\n", color.RedString("ALPN"), h.ALPN)
+	}
+
+	if h.Insecure != "" {
+		info += fmt.Sprintf("%s: %v\n", color.RedString("Insecure"), h.Insecure)
+	}
+
+	if h.Obfs != "" {
+		info += fmt.Sprintf("%s: %s\n%s: %s\n",
+			color.RedString("Obfuscation Type"), h.Obfs,
+			color.RedString("Obfuscation Password"), Redact(h.ObfsParam))
+	}
+	return info
+}
+
+func (h *Hysteria) ConvertToGeneralConfig() (g GeneralConfig) {
+	g.Protocol = h.Name()
+	g.Address = h.Address
+	g.Port = h.Port
+	g.Remark = h.Remark
+	g.TLS = "tls"
+	g.SNI = h.SNI
+	g.ALPN = h.ALPN
+	g.HysteriaProtocol = h.Protocol
+	g.AllowInsecure = h.Insecure
+
+	g.OrigLink = h.OrigLink
+
+	return g
+}
+
+func (h *Hysteria) ToLink() string {
+	query := BuildQuery(h)
+
+	link := fmt.Sprintf("%s://%s", HysteriaIdentifier, JoinHostPort(h.Address, h.Port))
+	if len(query) != 0 {
+		link += "?" + query.Encode()
+	}
+	if h.Remark != "" {
+		link += "#" + url.PathEscape(h.Remark)
+	}
+
+	return link
+}
@@ -0,0 +1,208 @@
+package protocol
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+func NewHysteria2(link string) *Hysteria2 {
+	return &Hysteria2{OrigLink: link}
+}
+
+func (h *Hysteria2) Name() string {
+	return Hysteria2Identifier
+}
+
+func (h *Hysteria2) Parse() error {
+	if err := checkScheme(Hysteria2Identifier, h.OrigLink, "hy2"); err != nil {
+		return err
+	}
+	// url.Parse rejects the multi-port form (host:443,20000-30000), so the ports are split out first
+	link, hopPorts := SplitHopPorts(h.OrigLink)
+
+	uri, err := parseURL(Hysteria2Identifier, link)
+	if err != nil {
+		return err
+	}
+
+	h.Password = uri.User.String()
+
+	h.Address, h.Port, err = splitHostPort(Hysteria2Identifier, uri.Host)
+	if err != nil {
+		return err
+	}
+
+	//if utils.IsIPv6(h.Address) {
+	//	v.Address = "[" + v.Address + "]"
+	//}
+
+	// Get the type of the struct
+	t := reflect.TypeOf(*h)
+
+	// Get the number of fields in the struct
+	numFields := t.NumField()
+
+	// Iterate over each field of the struct
+	for i := 0; i < numFields; i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		// If the query value exists for the field, set it
+		if values, ok := uri.Query()[tag]; ok {
+
+			value := values[0]
+			v := reflect.ValueOf(h).Elem().FieldByName(field.Name)
+
+			switch v.Type().String() {
+			case "string":
+				v.SetString(value)
+			case "int":
+				var intValue int
+				fmt.Sscanf(value, "%d", &intValue)
+				v.SetInt(int64(intValue))
+			}
+		}
+	}
+
+	if h.MPort == "" {
+		h.MPort = hopPorts
+	}
+	if h.MPort != "" {
+		if _, err = ParsePorts(h.MPort); err != nil {
+			return &ParseError{Scheme: Hysteria2Identifier, Field: "mport", Value: h.MPort, Reason: ReasonBadValue, Err: err}
+		}
+	}
+
+	// Parameter names used by other clients
+	aliases := []struct {
+		field *string
+		names []string
+	}{
+		{&h.Up, []string{"upmbps", "up_mbps"}},
+		{&h.Down, []string{"downmbps", "down_mbps"}},
+	}
+	for _, alias := range aliases {
+		for _, name := range alias.names {
+			if *alias.field == "" {
+				*alias.field = uri.Query().Get(name)
+			}
+		}
+	}
+
+	h.Remark, err = url.PathUnescape(uri.Fragment)
+	if err != nil {
+		h.Remark = uri.Fragment
+	}
+
+	return nil
+}
+
+func (h *Hysteria2) Validate() Diagnostics {
+	var d Diagnostics
+	d.CheckAddress("address", h.Address)
+	d.CheckPort("port", h.Port)
+	if h.Password == "" {
+		d.Warnf("password", "password is empty, most servers require one")
+	}
+
+	switch h.ObfusType {
+	case "":
+	case "salamander":
+		if h.ObfusPassword == "" {
+			d.Errorf("obfs-password", "salamander obfuscation needs a password")
+		}
+	default:
+		d.Errorf("obfs", "unknown obfuscation %q", h.ObfusType)
+	}
+
+	if _, err := ParseMbps(h.Up); h.Up != "" && err != nil {
+		d.Errorf("up", "%v", err)
+	}
+	if _, err := ParseMbps(h.Down); h.Down != "" && err != nil {
+		d.Errorf("down", "%v", err)
+	}
+	if h.PinSHA256 != "" {
+		d.Warnf("pinSHA256", "certificate pinning is not supported by sing-box core, the config can't be tested")
+	}
+
+	return d
+}
+
+func (h *Hysteria2) DetailsStr() string {
+	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %v\n%s: %s\n",
+		color.RedString("Protocol"), h.Name(),
+		color.RedString("Remark"), h.Remark,
+		color.RedString("Address"), h.Address,
+		color.RedString("Port"), h.Port,
+		color.RedString("Password"), Redact(h.Password),
+		color.RedString("SNI"), h.SNI)
+
+	if h.Insecure != "" {
+		info += fmt.Sprintf("%s: %v\n",
+			color.RedString("Insecure"), h.Insecure)
+	}
+
+	if h.ObfusType != "" {
+		info += fmt.Sprintf("%s: %s\n%s: %s\n",
+			color.RedString("Obfuscation Type"), h.ObfusType,
+			color.RedString("Obfuscation Password"), Redact(h.ObfusPassword))
+	}
+
+	if h.MPort != "" {
+		info += fmt.Sprintf("%s: %s (tested on %s)\n", color.RedString("Port Hopping"), h.MPort, h.HopPort())
+	}
+	if h.Up != "" {
+		info += fmt.Sprintf("%s: %s\n", color.RedString("Up"), h.Up)
+	}
+	if h.Down != "" {
+		info += fmt.Sprintf("%s: %s\n", color.RedString("Down"), h.Down)
+	}
+	if h.ALPN != "" {
+		info += fmt.Sprintf("%s: %s\n", color.RedString("ALPN"), h.ALPN)
+	}
+	if h.PinSHA256 != "" {
+		info += fmt.Sprintf("%s: %s\n", color.RedString("Certificate SHA256"), h.PinSHA256)
+	}
+	return info
+}
+
+// HopPort returns the port the cores connect to: the first one of the port hopping list,
+// so every test of a config uses the same port. Without port hopping it's the port of the link.
+func (h *Hysteria2) HopPort() string {
+	if ranges, err := ParsePorts(h.MPort); h.MPort != "" && err == nil {
+		return strconv.Itoa(ranges[0][0])
+	}
+	return h.Port
+}
+
+func (h *Hysteria2) ConvertToGeneralConfig() (g GeneralConfig) {
+	g.Protocol = h.Name()
+	g.Address = h.Address
+	g.Port = h.Port
+	g.Remark = h.Remark
+	g.TLS = "tls"
+	g.SNI = h.SNI
+	g.ALPN = h.ALPN
+	g.AllowInsecure = h.Insecure
+
+	g.OrigLink = h.OrigLink
+
+	return g
+}
+
+func (h *Hysteria2) ToLink() string {
+	query := BuildQuery(h)
+
+	link := fmt.Sprintf("%s://%s@%s/", Hysteria2Identifier, h.Password, JoinHostPort(h.Address, h.Port))
+	if len(query) != 0 {
+		link += "?" + query.Encode()
+	}
+	if h.Remark != "" {
+		link += "#" + url.PathEscape(h.Remark)
+	}
+
+	return link
+}
@@ -8,6 +8,10 @@ const (
 	WireguardIdentifier   = "wireguard"
 	SocksIdentifier       = "socks"
 	Hysteria2Identifier   = "hysteria2"
+	TuicIdentifier        = "tuic"
+	HysteriaIdentifier    = "hysteria"
+	HttpIdentifier        = "http"
+	HttpsIdentifier       = "https"
 )
 const (
 	VmessPattern       = `vmess:\/\/[a-zA-Z0-9+/=]+`
@@ -23,28 +27,250 @@ type Instance interface {
 
 type Protocol interface {
 	Parse() error
+	Validate() Diagnostics // Reports the problems of a parsed config, see Diagnostics
 	DetailsStr() string
 	ConvertToGeneralConfig() GeneralConfig
+	ToLink() string
 }
 
 type GeneralConfig struct {
-	Protocol       string
+	Protocol         string
+	Address          string
+	Security         string
+	Aid              string
+	Host             string
+	ID               string
+	Network          string
+	Path             string
+	Port             string
+	Remark           string
+	TLS              string
+	SNI              string
+	ALPN             string
+	TlsFingerprint   string
+	Authority        string
+	ServiceName      string
+	Mode             string
+	Type             string
+	HeaderType       string
+	Flow             string
+	PublicKey        string // REALITY
+	ShortID          string // REALITY
+	SpiderX          string // REALITY
+	HysteriaProtocol string // Hysteria: udp or faketcp, not a transport
+	AllowInsecure    string
+	OrigLink         string
+}
+
+type Vmess struct {
+	Version        interface{} `json:"v"`
+	Address        string      `json:"add"`
+	Aid            interface{} `json:"aid"` // AlterID
+	Port           interface{} `json:"port"`
+	Security       string      `json:"scy"`
+	Host           string      `json:"host"`
+	ID             string      `json:"id"`
+	Network        string      `json:"net"`
+	Path           string      `json:"path"`
+	Remark         string      `json:"ps"` // Config's name
+	TLS            string      `json:"tls"`
+	AllowInsecure  interface{} `json:"allowinsecure"`
+	SNI            string      `json:"sni"`  // Server name indication
+	ALPN           string      `json:"alpn"` // Application-Layer Protocol Negotiation
+	TlsFingerprint string      `json:"fp"`   // TLS fingerprint
+	Type           string      `json:"type"` // XHTTP - Used for HTTP Obfuscation
+
+	//// It's also possible for Vmess to have REALITY...
+	//PublicKey string `json:"pbk"`
+	//ShortIds  string `json:"sid"` // Mandatory, the shortId list available to the client, which can be used to distinguish different clients
+	//SpiderX   string `json:"spx"` // Reality path
+
+	// Multiplexing, see Mux
+	Mux             interface{} `json:"mux,omitempty"` // 1 or 0
+	MuxConcurrency  interface{} `json:"muxConcurrency,omitempty"`
+	XudpConcurrency interface{} `json:"xudpConcurrency,omitempty"`
+	XudpProxyUDP443 interface{} `json:"xudpProxyUDP443,omitempty"`
+	MuxProtocol     interface{} `json:"muxProtocol,omitempty"` // smux, yamux or h2mux
+	MuxPadding      interface{} `json:"muxPadding,omitempty"`
+
+	OrigLink string `json:"-"` // Original link
+	Alias    string `json:"-"` // Scheme of the alias that made it, see Registry.Register
+}
+
+type Vless struct {
+	LinkVersion    string `json:"-"`
+	ID             string `json:"id"`  // UUID
+	Address        string `json:"add"` // HOST:PORT
+	Encryption     string `json:"encryption"`
+	Flow           string `json:"flow"`
+	QuicSecurity   string `json:"quicSecurity"`
+	Key            string `json:"key"`      // Quic key
+	Security       string `json:"security"` // reality or tls
+	PublicKey      string `json:"pbk"`
+	ShortIds       string `json:"sid"`        // Mandatory, the shortId list available to the client, which can be used to distinguish different clients
+	SpiderX        string `json:"spx"`        // Reality path
+	HeaderType     string `json:"headerType"` // TCP HTTP Obfuscation
+	Host           string `json:"host"`       // HTTP, WS
+	Path           string `json:"path"`
+	Port           string `json:"port"`
+	SNI            string `json:"sni"`           // Server name indication
+	ALPN           string `json:"alpn"`          // Application-Layer Protocol Negotiation
+	TlsFingerprint string `json:"fp"`            // TLS fingerprint
+	AllowInsecure  string `json:"allowInsecure"` // Insecure TLS
+	Type           string `json:"type"`          // Network (XHTTP, ...)
+	Remark         string `json:"ps"`            // Config's name
+	Authority      string `json:"authority"`     // GRPC
+	ServiceName    string `json:"serviceName"`   // GRPC
+	Mode           string `json:"mode"`          // XHTTP - GRPC
+
+	// Multiplexing, see Mux
+	Mux             string `json:"mux"` // 1 or 0
+	MuxConcurrency  string `json:"muxConcurrency"`
+	XudpConcurrency string `json:"xudpConcurrency"`
+	XudpProxyUDP443 string `json:"xudpProxyUDP443"`
+	MuxProtocol     string `json:"muxProtocol"` // smux, yamux or h2mux
+	MuxPadding      string `json:"muxPadding"`
+
+	OrigLink string `json:"-"` // Original link
+	Alias    string `json:"-"` // Scheme of the alias that made it, see Registry.Register
+}
+
+type Shadowsocks struct {
+	Address    string
+	Port       string
+	Encryption string
+	Password   string
+	Remark     string
+	Plugin     string // SIP002 plugin (obfs-local, v2ray-plugin, shadow-tls)
+	PluginOpts string // SIP002 plugin options, separated by semicolons
+	OrigLink   string // Original link
+	Alias      string // Scheme of the alias that made it, see Registry.Register
+}
+
+type Trojan struct {
+	LinkVersion    string `json:"-"`
+	Password       string // Password
+	Address        string `json:"add"` // HOST:PORT
+	Flow           string `json:"flow"`
+	QuicSecurity   string `json:"quicSecurity"`
+	Key            string `json:"key"`        // Quic key
+	Security       string `json:"security"`   // tls
+	HeaderType     string `json:"headerType"` // TCP HTTP Obfuscation
+	Host           string `json:"host"`       // HTTP, WS
+	Path           string `json:"path"`
+	Port           string `json:"port"`
+	SNI            string `json:"sni"`           // Server name indication
+	ALPN           string `json:"alpn"`          // Application-Layer Protocol Negotiation
+	TlsFingerprint string `json:"fp"`            // TLS fingerprint
+	AllowInsecure  string `json:"allowInsecure"` // Insecure TLS
+	Type           string `json:"type"`          // Network (XHTTP, ...)
+	Remark         string // Config's name
+	Authority      string `json:"authority"`   // GRPC
+	ServiceName    string `json:"serviceName"` // GRPC
+	Mode           string `json:"mode"`        // XHTTP, GRPC
+
+	// Yes, Trojan can have reality too xD
+	PublicKey string `json:"pbk"`
+	ShortIds  string `json:"sid"` // Mandatory, the shortId list available to the client, which can be used to distinguish different clients
+	SpiderX   string `json:"spx"` // Reality path
+
+	// Multiplexing, see Mux
+	Mux             string `json:"mux"` // 1 or 0
+	MuxConcurrency  string `json:"muxConcurrency"`
+	XudpConcurrency string `json:"xudpConcurrency"`
+	XudpProxyUDP443 string `json:"xudpProxyUDP443"`
+	MuxProtocol     string `json:"muxProtocol"` // smux, yamux or h2mux
+	MuxPadding      string `json:"muxPadding"`
+
+	OrigLink string `json:"-"` // Original link
+	Alias    string `json:"-"` // Scheme of the alias that made it, see Registry.Register
+}
+
+type Wireguard struct {
+	Remark       string
+	PublicKey    string `json:"publickey"`
+	SecretKey    string `json:"secretkey"`
+	PreSharedKey string `json:"presharedkey"`
+	Endpoint     string
+	Reserved     string `json:"reserved"` // Reserved bytes seperated by commas
+	LocalAddress string `json:"address"`  // Local address IPv4/IPv6 seperated by commas
+	Mtu          int32  `json:"mtu"`
+
+	OrigLink string `json:"-"` // Original link
+	Alias    string `json:"-"` // Scheme of the alias that made it, see Registry.Register
+}
+
+type Socks struct {
+	Remark   string
+	Address  string // HOST:PORT
+	Port     string
+	Username string // Username
+	Password string // Password
+	OrigLink string // Original link
+	Alias    string // Scheme of the alias that made it, see Registry.Register
+}
+
+type Http struct {
+	Remark         string
 	Address        string
-	Security       string
-	Aid            string
-	Host           string
-	ID             string
-	Network        string
-	Path           string
 	Port           string
-	Remark         string
-	TLS            string
-	SNI            string
-	ALPN           string
-	TlsFingerprint string
-	Authority      string
-	ServiceName    string
-	Mode           string
-	Type           string
-	OrigLink       string
+	Username       string
+	Password       string
+	TLS            string // "tls" for https links
+	SNI            string `json:"sni"`           // Server name indication
+	TlsFingerprint string `json:"fp"`            // TLS fingerprint
+	AllowInsecure  string `json:"allowInsecure"` // Insecure TLS
+	OrigLink       string `json:"-"`             // Original link
+	Alias          string `json:"-"`             // Scheme of the alias that made it, see Registry.Register
+}
+
+type Hysteria struct {
+	Remark    string
+	Address   string
+	Port      string
+	Protocol  string `json:"protocol"` // udp, faketcp
+	Auth      string `json:"auth"`
+	SNI       string `json:"peer"`
+	Insecure  string `json:"insecure"`
+	UpMbps    string `json:"upmbps"`
+	DownMbps  string `json:"downmbps"`
+	ALPN      string `json:"alpn"`
+	Obfs      string `json:"obfs"`      // xplus
+	ObfsParam string `json:"obfsParam"` // Obfuscation password
+	OrigLink  string // Original link
+	Alias     string // Scheme of the alias that made it, see Registry.Register
+}
+
+type Hysteria2 struct {
+	Remark        string
+	Address       string
+	Port          string
+	Password      string
+	ObfusType     string `json:"obfs"`
+	ObfusPassword string `json:"obfs-password"`
+	SNI           string `json:"sni"`
+	Insecure      string `json:"insecure"`
+	MPort         string `json:"mport"`     // Port hopping ports, e.g. "20000-30000" or "443,20000-30000"
+	Up            string `json:"up"`        // Upload bandwidth, e.g. "100 Mbps"
+	Down          string `json:"down"`      // Download bandwidth
+	ALPN          string `json:"alpn"`      // Application-Layer Protocol Negotiation
+	PinSHA256     string `json:"pinSHA256"` // SHA256 of the server certificate
+	OrigLink      string // Original link
+	Alias         string // Scheme of the alias that made it, see Registry.Register
+}
+
+type Tuic struct {
+	Remark            string
+	Address           string
+	Port              string
+	UUID              string
+	Password          string
+	CongestionControl string `json:"congestion_control"` // cubic, new_reno, bbr
+	UDPRelayMode      string `json:"udp_relay_mode"`     // native, quic
+	ALPN              string `json:"alpn"`
+	SNI               string `json:"sni"`
+	Insecure          string `json:"allow_insecure"`
+	DisableSNI        string `json:"disable_sni"`
+	OrigLink          string // Original link
+	Alias             string // Scheme of the alias that made it, see Registry.Register
 }
@@ -0,0 +1,155 @@
+package protocol
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// BuildQuery collects the non-empty fields of a link struct into url.Values, keyed by
+// their json tag. It's the reverse of the reflection loop used by the Parse methods.
+// Fields without a json tag, tagged with "-" or listed in skip are left out.
+func BuildQuery(v interface{}, skip ...string) url.Values {
+	query := url.Values{}
+
+	val := reflect.Indirect(reflect.ValueOf(v))
+	t := val.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		if tag == "" || tag == "-" || contains(skip, tag) {
+			continue
+		}
+
+		field := val.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			if field.String() != "" {
+				query.Set(tag, field.String())
+			}
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			if field.Int() != 0 {
+				query.Set(tag, fmt.Sprintf("%d", field.Int()))
+			}
+		}
+	}
+
+	return query
+}
+
+// JoinHostPort is like net.JoinHostPort but accepts IPv6 addresses that are already
+// wrapped in brackets (as the Parse methods store them).
+func JoinHostPort(host string, port string) string {
+	return net.JoinHostPort(strings.Trim(host, "[]"), port)
+}
+
+// ParsePluginOpts splits SIP002 plugin options ("key1=value1;key2=value2").
+// Flags without a value (like "tls" of v2ray-plugin) are kept with an empty value.
+func ParsePluginOpts(opts string) map[string]string {
+	m := make(map[string]string)
+	for _, opt := range strings.Split(opts, ";") {
+		if strings.TrimSpace(opt) == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(opt, "=")
+		m[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return m
+}
+
+// SplitHopPorts replaces a multi-port authority (hysteria2://pw@host:443,20000-30000) by its first port
+// and returns the whole port list, which url.Parse would reject. Links with a single port are returned as they are.
+func SplitHopPorts(link string) (string, string) {
+	scheme, rest, ok := strings.Cut(link, "://")
+	if !ok {
+		return link, ""
+	}
+
+	end := strings.IndexAny(rest, "/?#")
+	if end == -1 {
+		end = len(rest)
+	}
+	authority := rest[:end]
+
+	hostStart := strings.LastIndex(authority, "@") + 1
+	portStart := strings.LastIndex(authority, ":")
+	if portStart < hostStart {
+		return link, ""
+	}
+	ports := authority[portStart+1:]
+	if !strings.ContainsAny(ports, ",-") {
+		return link, ""
+	}
+
+	first := strings.FieldsFunc(ports, func(r rune) bool { return r == ',' || r == '-' })
+	if len(first) == 0 {
+		return link, ""
+	}
+
+	return scheme + "://" + authority[:portStart+1] + first[0] + rest[end:], ports
+}
+
+// ParsePorts parses a port hopping list ("443,20000-30000") into inclusive port ranges
+func ParsePorts(ports string) ([][2]int, error) {
+	var ranges [][2]int
+	for _, part := range strings.Split(ports, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		if !isRange {
+			to = from
+		}
+		start, err1 := strconv.Atoi(from)
+		end, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil || start < 1 || end > 65535 || start > end {
+			return nil, fmt.Errorf("invalid port range %q", part)
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges, nil
+}
+
+// ParseMbps converts a bandwidth like "100", "100 Mbps" or "1 Gbps" into Mbps
+func ParseMbps(bandwidth string) (int, error) {
+	value := strings.ToLower(strings.ReplaceAll(bandwidth, " ", ""))
+	end := strings.IndexFunc(value, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if end == -1 {
+		end = len(value)
+	}
+
+	number, err := strconv.ParseFloat(value[:end], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth %q", bandwidth)
+	}
+
+	switch strings.TrimSuffix(value[end:], "ps") {
+	case "b":
+		number /= 1000000
+	case "k", "kb":
+		number /= 1000
+	case "", "m", "mb":
+	case "g", "gb":
+		number *= 1000
+	case "t", "tb":
+		number *= 1000000
+	default:
+		return 0, fmt.Errorf("invalid bandwidth %q", bandwidth)
+	}
+
+	mbps := int(math.Round(number))
+	if mbps == 0 && number > 0 {
+		mbps = 1
+	}
+	return mbps, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package protocol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// Mux holds the multiplexing options of an outbound: the xray mux (with XUDP) and the sing-box multiplex
+type Mux struct {
+	Enabled         bool
+	Concurrency     int    // xray concurrency, sing-box max_streams (0 is the core's default)
+	XudpConcurrency int    // xray only
+	XudpProxyUDP443 string // xray only: reject, allow or skip
+	Protocol        string // sing-box only: smux, yamux or h2mux
+	Padding         bool   // sing-box only
+}
+
+// String describes the options, e.g. "on (concurrency 8, smux, padding)"
+func (m Mux) String() string {
+	if !m.Enabled {
+		return "off"
+	}
+	var opts []string
+	if m.Concurrency != 0 {
+		opts = append(opts, fmt.Sprintf("concurrency %d", m.Concurrency))
+	}
+	if m.XudpConcurrency != 0 {
+		opts = append(opts, fmt.Sprintf("xudp concurrency %d", m.XudpConcurrency))
+	}
+	if m.XudpProxyUDP443 != "" {
+		opts = append(opts, "udp/443 "+m.XudpProxyUDP443)
+	}
+	if m.Protocol != "" {
+		opts = append(opts, m.Protocol)
+	}
+	if m.Padding {
+		opts = append(opts, "padding")
+	}
+	if len(opts) == 0 {
+		return "on"
+	}
+	return "on (" + strings.Join(opts, ", ") + ")"
+}
+
+// Validate checks the values the cores would reject
+func (m Mux) Validate() error {
+	switch m.XudpProxyUDP443 {
+	case "", "reject", "allow", "skip":
+	default:
+		return fmt.Errorf("unknown xudpProxyUDP443 %q, expected reject, allow or skip", m.XudpProxyUDP443)
+	}
+	switch m.Protocol {
+	case "", "smux", "yamux", "h2mux":
+	default:
+		return fmt.Errorf("unknown mux protocol %q, expected smux, yamux or h2mux", m.Protocol)
+	}
+	if m.Concurrency < -1 || m.Concurrency > 1024 {
+		return fmt.Errorf("mux concurrency %d out of range [-1, 1024]", m.Concurrency)
+	}
+	if m.XudpConcurrency < -1 || m.XudpConcurrency > 1024 {
+		return fmt.Errorf("xudp concurrency %d out of range [-1, 1024]", m.XudpConcurrency)
+	}
+	return nil
+}
+
+var (
+	defaultMuxMu sync.RWMutex
+	defaultMux   Mux
+)
+
+// SetMux sets the multiplexing of the outbounds whose link doesn't set it (the global --mux flags)
+func SetMux(m Mux) {
+	defaultMuxMu.Lock()
+	defer defaultMuxMu.Unlock()
+	defaultMux = m
+}
+
+// DefaultMux returns the multiplexing set by SetMux, off by default
+func DefaultMux() Mux {
+	defaultMuxMu.RLock()
+	defer defaultMuxMu.RUnlock()
+	return defaultMux
+}
+
+// muxParams are the multiplexing parameters of a link, empty when not set:
+// mux=1, muxConcurrency=8, xudpConcurrency=16, xudpProxyUDP443=reject, muxProtocol=h2mux, muxPadding=1
+type muxParams struct {
+	mux, concurrency, xudpConcurrency, xudpProxyUDP443, protocol, padding string
+}
+
+func (p muxParams) isSet() bool {
+	return p != muxParams{}
+}
+
+// apply overrides m with the parameters set in the link
+func (p muxParams) apply(scheme string, m *Mux) error {
+	parseBool := func(field, value string) (bool, error) {
+		switch strings.ToLower(value) {
+		case "1", "true", "on":
+			return true, nil
+		case "0", "false", "off":
+			return false, nil
+		}
+		return false, &ParseError{Scheme: scheme, Field: field, Value: value, Reason: ReasonBadValue}
+	}
+	parseInt := func(field, value string) (int, error) {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, &ParseError{Scheme: scheme, Field: field, Value: value, Reason: ReasonBadValue, Err: err}
+		}
+		return n, nil
+	}
+
+	var err error
+	if p.mux != "" {
+		if m.Enabled, err = parseBool("mux", p.mux); err != nil {
+			return err
+		}
+	}
+	if p.concurrency != "" {
+		if m.Concurrency, err = parseInt("muxConcurrency", p.concurrency); err != nil {
+			return err
+		}
+	}
+	if p.xudpConcurrency != "" {
+		if m.XudpConcurrency, err = parseInt("xudpConcurrency", p.xudpConcurrency); err != nil {
+			return err
+		}
+	}
+	if p.xudpProxyUDP443 != "" {
+		m.XudpProxyUDP443 = p.xudpProxyUDP443
+	}
+	if p.protocol != "" {
+		m.Protocol = p.protocol
+	}
+	if p.padding != "" {
+		if m.Padding, err = parseBool("muxPadding", p.padding); err != nil {
+			return err
+		}
+	}
+
+	if err = m.Validate(); err != nil {
+		return &ParseError{Scheme: scheme, Field: "mux", Reason: ReasonBadValue, Err: err}
+	}
+	return nil
+}
+
+// muxParamsOf returns the multiplexing parameters of the links that can carry them (vless, vmess, trojan)
+func muxParamsOf(p Protocol) (string, muxParams) {
+	str := func(v interface{}) string {
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprintf("%v", v)
+	}
+
+	switch v := p.(type) {
+	case *Vless:
+		return VlessIdentifier, muxParams{v.Mux, v.MuxConcurrency, v.XudpConcurrency, v.XudpProxyUDP443, v.MuxProtocol, v.MuxPadding}
+	case *Trojan:
+		return TrojanIdentifier, muxParams{v.Mux, v.MuxConcurrency, v.XudpConcurrency, v.XudpProxyUDP443, v.MuxProtocol, v.MuxPadding}
+	case *Vmess:
+		return VmessIdentifier, muxParams{str(v.Mux), str(v.MuxConcurrency), str(v.XudpConcurrency), str(v.XudpProxyUDP443), str(v.MuxProtocol), str(v.MuxPadding)}
+	}
+	return "", muxParams{}
+}
+
+// MuxOf returns the multiplexing of a parsed config: the one of SetMux, overridden by the parameters of its link
+func MuxOf(p Protocol) Mux {
+	m := DefaultMux()
+	if scheme, params := muxParamsOf(p); params.isSet() {
+		// Parse already rejected the bad values
+		_ = params.apply(scheme, &m)
+	}
+	return m
+}
+
+// muxDetails is the details line of the multiplexing of a link that sets it
+func muxDetails(p Protocol) string {
+	if _, params := muxParamsOf(p); !params.isSet() {
+		return ""
+	}
+	return fmt.Sprintf("%s: %s\n", color.RedString("Mux"), MuxOf(p))
+}
+
+// checkMux reports the bad multiplexing parameters of a link
+func checkMux(p Protocol) error {
+	scheme, params := muxParamsOf(p)
+	if !params.isSet() {
+		return nil
+	}
+	var m Mux
+	return params.apply(scheme, &m)
+}
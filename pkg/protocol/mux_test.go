@@ -0,0 +1,51 @@
+package protocol
+
+import "testing"
+
+func TestMuxOf(t *testing.T) {
+	SetMux(Mux{Enabled: true, Concurrency: 4, XudpProxyUDP443: "skip"})
+	defer SetMux(Mux{})
+
+	v := NewVless("vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?muxConcurrency=8&muxProtocol=h2mux&muxPadding=1#mux")
+	if err := v.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	want := Mux{Enabled: true, Concurrency: 8, XudpProxyUDP443: "skip", Protocol: "h2mux", Padding: true}
+	if got := MuxOf(v); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// A link can turn off the default, and keeps its parameters in ToLink
+	tr := NewTrojan("trojan://pw@example.com:443?mux=0#no-mux")
+	if err := tr.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if MuxOf(tr).Enabled {
+		t.Error("the link didn't turn off the mux")
+	}
+	if got, want := tr.ToLink(), "trojan://pw@example.com:443?fp=chrome&mux=0&security=tls&type=tcp#no-mux"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// Protocols without link parameters get the default
+	if got := MuxOf(NewSocks("socks://example.com:1080")); got != DefaultMux() {
+		t.Errorf("got %+v, want the default", got)
+	}
+}
+
+func TestMux_BadValues(t *testing.T) {
+	for _, link := range []string{
+		"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?mux=maybe",
+		"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?mux=1&muxConcurrency=many",
+		"trojan://pw@example.com:443?mux=1&muxProtocol=quic",
+		"trojan://pw@example.com:443?mux=1&xudpProxyUDP443=drop",
+	} {
+		p, err := CreateProtocol(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = p.Parse(); ReasonOf(err) != ReasonBadValue {
+			t.Errorf("%s: expected a bad value error, got %v", link, err)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package protocol
+
+// Builder is implemented by the cores. It compiles the core-agnostic protocols
+// of this package into the protocols of the core, which add the outbound building methods.
+type Builder interface {
+	Build(p Protocol) (Protocol, error)
+}
+
+// CreateProtocol returns the core-agnostic protocol of a config link (not parsed yet), see Register
+func CreateProtocol(configLink string) (Protocol, error) {
+	return defaultRegistry.CreateProtocol(configLink)
+}
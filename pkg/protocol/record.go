@@ -0,0 +1,137 @@
+package protocol
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Security is the security layer of a config
+type Security string
+
+const (
+	SecurityNone    Security = "none"
+	SecurityTLS     Security = "tls"
+	SecurityReality Security = "reality"
+)
+
+// Record is the typed form of GeneralConfig, meant for machine-readable outputs (e.g. parse --format json).
+// Optional fields are omitted from the JSON when empty.
+type Record struct {
+	Protocol         string         `json:"protocol"`
+	Remark           string         `json:"remark"`
+	Address          string         `json:"address"`
+	Port             int            `json:"port"` // 0 when the config has no valid port
+	Security         Security       `json:"security"`
+	Transport        *TransportInfo `json:"transport,omitempty"` // Set for the protocols with a transport (network) choice
+	TLS              *TLSInfo       `json:"tls,omitempty"`       // Set with the tls and reality security
+	Reality          *RealityInfo   `json:"reality,omitempty"`   // Set with the reality security
+	Flow             string         `json:"flow,omitempty"`
+	HysteriaProtocol string         `json:"hysteria_protocol,omitempty"` // Hysteria: udp or faketcp
+	Link             string         `json:"link"`
+}
+
+// TransportInfo is the transport (network) of a Record
+type TransportInfo struct {
+	Network     string `json:"network"` // tcp, ws, grpc, httpupgrade, xhttp, ...
+	HeaderType  string `json:"header_type,omitempty"`
+	Host        string `json:"host,omitempty"`
+	Path        string `json:"path,omitempty"`
+	ServiceName string `json:"service_name,omitempty"` // grpc
+	Authority   string `json:"authority,omitempty"`    // grpc
+	Mode        string `json:"mode,omitempty"`         // grpc, xhttp
+}
+
+// TLSInfo is the TLS layer of a Record, REALITY included
+type TLSInfo struct {
+	SNI         string   `json:"sni,omitempty"`
+	ALPN        []string `json:"alpn,omitempty"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	Insecure    bool     `json:"insecure"`
+}
+
+// RealityInfo holds the REALITY parameters of a Record
+type RealityInfo struct {
+	PublicKey string `json:"public_key"`
+	ShortID   string `json:"short_id,omitempty"`
+	SpiderX   string `json:"spider_x,omitempty"`
+}
+
+// securityOf returns the security layer of a general config
+func securityOf(g GeneralConfig) Security {
+	switch security := strings.ToLower(g.TLS); security {
+	case "", "none":
+		return SecurityNone
+	case "tls", "xtls":
+		return SecurityTLS
+	default:
+		return Security(security)
+	}
+}
+
+// Record converts the general config into its typed form.
+// The secrets are kept as they are, the outputs use Redacted.
+func (g GeneralConfig) Record() Record {
+	r := Record{
+		Protocol:         g.Protocol,
+		Remark:           g.Remark,
+		Address:          strings.Trim(g.Address, "[]"),
+		Security:         securityOf(g),
+		Flow:             g.Flow,
+		HysteriaProtocol: g.HysteriaProtocol,
+		Link:             g.OrigLink,
+	}
+	r.Port, _ = strconv.Atoi(g.Port)
+
+	if r.Flow == "none" {
+		r.Flow = ""
+	}
+
+	if g.Network != "" {
+		r.Transport = &TransportInfo{
+			Network:     g.Network,
+			HeaderType:  g.HeaderType,
+			Host:        g.Host,
+			Path:        g.Path,
+			ServiceName: g.ServiceName,
+			Authority:   g.Authority,
+			Mode:        g.Mode,
+		}
+		if r.Transport.HeaderType == "none" {
+			r.Transport.HeaderType = ""
+		}
+	}
+
+	if r.Security == SecurityTLS || r.Security == SecurityReality {
+		r.TLS = &TLSInfo{
+			SNI:         g.SNI,
+			Fingerprint: g.TlsFingerprint,
+			Insecure:    g.AllowInsecure == "1" || g.AllowInsecure == "true",
+		}
+		if g.ALPN != "" && g.ALPN != "none" {
+			r.TLS.ALPN = strings.Split(g.ALPN, ",")
+		}
+		if r.TLS.Fingerprint == "none" {
+			r.TLS.Fingerprint = ""
+		}
+	}
+	if r.Security == SecurityReality {
+		r.Reality = &RealityInfo{
+			PublicKey: g.PublicKey,
+			ShortID:   g.ShortID,
+			SpiderX:   g.SpiderX,
+		}
+	}
+
+	return r
+}
+
+// Redacted returns the record with its secrets masked when the redaction is enabled, see RedactLink
+func (r Record) Redacted() Record {
+	r.Link = RedactLink(r.Link)
+	if r.Reality != nil {
+		reality := *r.Reality
+		reality.ShortID = Redact(reality.ShortID)
+		r.Reality = &reality
+	}
+	return r
+}
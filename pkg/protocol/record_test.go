@@ -0,0 +1,49 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGeneralConfig_Record(t *testing.T) {
+	link := "vless://0090bbba-1118-46ca-87a1-52599cee74ab@[2606:4700::6810:85e5]:443?security=reality&pbk=7xhH4b_VkliBxGulljcyPOH-bYUA2dl-XAdZAsfhk2U&sid=6ba85179e30d4fc2&sni=www.speedtest.net&fp=chrome&alpn=h2%2Chttp%2F1.1&type=grpc&serviceName=grpc#reality"
+	v := NewVless(link)
+	if err := v.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Record{
+		Protocol:  "vless",
+		Remark:    "reality",
+		Address:   "2606:4700::6810:85e5",
+		Port:      443,
+		Security:  SecurityReality,
+		Transport: &TransportInfo{Network: "grpc", ServiceName: "grpc"},
+		TLS:       &TLSInfo{SNI: "www.speedtest.net", ALPN: []string{"h2", "http/1.1"}, Fingerprint: "chrome"},
+		Reality:   &RealityInfo{PublicKey: "7xhH4b_VkliBxGulljcyPOH-bYUA2dl-XAdZAsfhk2U", ShortID: "6ba85179e30d4fc2"},
+		Link:      link,
+	}
+	if got := v.ConvertToGeneralConfig().Record(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected record\ngot:  %+v\nwant: %+v", got, want)
+	}
+
+	// Protocols without a transport or TLS only have the common fields
+	s := NewShadowsocks("ss://YWVzLTI1Ni1nY206c2VjcmV0@example.com:8388#ss")
+	if err := s.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	r := s.ConvertToGeneralConfig().Record()
+	if r.Port != 8388 || r.Security != SecurityNone || r.Transport != nil || r.TLS != nil || r.Reality != nil {
+		t.Errorf("unexpected shadowsocks record: %+v", r)
+	}
+
+	// The hysteria protocol is an obfuscation mode, not a transport
+	h := NewHysteria("hysteria://example.com:443?protocol=faketcp&auth=secret#faketcp")
+	if err := h.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	r = h.ConvertToGeneralConfig().Record()
+	if r.Transport != nil || r.HysteriaProtocol != "faketcp" {
+		t.Errorf("unexpected hysteria record: %+v", r)
+	}
+}
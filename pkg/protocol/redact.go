@@ -0,0 +1,94 @@
+package protocol
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+)
+
+// redact is the --redact mode, shared by every formatter
+var redact atomic.Bool
+
+// SetRedact enables or disables the masking of secrets (UUIDs, passwords, private keys, REALITY short ids)
+// in the details, links and reports
+func SetRedact(enabled bool) {
+	redact.Store(enabled)
+}
+
+// Redacting reports whether the secrets are masked
+func Redacting() bool {
+	return redact.Load()
+}
+
+// Redact masks a secret when the redaction is enabled.
+// The mask keeps a short hash of the secret, so the same secret always gets the same mask
+// and redacted entries can still be correlated.
+func Redact(secret string) string {
+	if !Redacting() || secret == "" {
+		return secret
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return "redacted-" + hex.EncodeToString(sum[:4])
+}
+
+// redactPluginOpts masks the password of SIP002 plugin options (shadow-tls)
+func redactPluginOpts(opts string) string {
+	if !Redacting() {
+		return opts
+	}
+	parts := strings.Split(opts, ";")
+	for i, part := range parts {
+		if key, value, ok := strings.Cut(part, "="); ok && key == "password" {
+			parts[i] = key + "=" + Redact(value)
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// redactSecrets masks the secrets of a protocol struct in place
+func redactSecrets(p Protocol) {
+	switch v := p.(type) {
+	case *Vmess:
+		v.ID = Redact(v.ID)
+	case *Vless:
+		v.ID = Redact(v.ID)
+		v.ShortIds = Redact(v.ShortIds)
+	case *Trojan:
+		v.Password = Redact(v.Password)
+		v.ShortIds = Redact(v.ShortIds)
+	case *Shadowsocks:
+		v.Password = Redact(v.Password)
+		v.PluginOpts = redactPluginOpts(v.PluginOpts)
+	case *Socks:
+		v.Username, v.Password = Redact(v.Username), Redact(v.Password)
+	case *Http:
+		v.Username, v.Password = Redact(v.Username), Redact(v.Password)
+	case *Wireguard:
+		v.SecretKey, v.PreSharedKey = Redact(v.SecretKey), Redact(v.PreSharedKey)
+	case *Hysteria:
+		v.Auth, v.ObfsParam = Redact(v.Auth), Redact(v.ObfsParam)
+	case *Hysteria2:
+		v.Password, v.ObfusPassword = Redact(v.Password), Redact(v.ObfusPassword)
+	case *Tuic:
+		v.UUID, v.Password = Redact(v.UUID), Redact(v.Password)
+	}
+}
+
+// RedactLink masks the secrets of a config link when the redaction is enabled.
+// The link is re-encoded with the masked values, a link that can't be parsed is masked as a whole.
+func RedactLink(link string) string {
+	if !Redacting() || link == "" {
+		return link
+	}
+
+	p, err := CreateProtocol(link)
+	if err == nil {
+		err = p.Parse()
+	}
+	if err != nil {
+		return Redact(link)
+	}
+	redactSecrets(p)
+	return p.ToLink()
+}
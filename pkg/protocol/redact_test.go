@@ -0,0 +1,48 @@
+package protocol
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	const id = "0090bbba-1118-46ca-87a1-52599cee74ab"
+	link := "vless://" + id + "@example.com:443?security=reality&pbk=7xhH4b_VkliBxGulljcyPOH-bYUA2dl-XAdZAsfhk2U&sid=6ba85179e30d4fc2&sni=example.com#reality"
+
+	if Redact(id) != id || RedactLink(link) != link {
+		t.Fatal("secrets are masked while the redaction is disabled")
+	}
+
+	SetRedact(true)
+	defer SetRedact(false)
+
+	masked := Redact(id)
+	if masked == id || masked != Redact(id) || masked == Redact("other") {
+		t.Errorf("expected a stable mask per secret, got %q", masked)
+	}
+
+	v := NewVless(link)
+	if err := v.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	for name, output := range map[string]string{
+		"details": v.DetailsStr(),
+		"link":    RedactLink(link),
+		"record":  v.ConvertToGeneralConfig().Record().Redacted().Link,
+	} {
+		if strings.Contains(output, id) || strings.Contains(output, "6ba85179e30d4fc2") {
+			t.Errorf("%s output has secrets: %s", name, output)
+		}
+		if !strings.Contains(output, masked) {
+			t.Errorf("%s output doesn't have the mask %s: %s", name, masked, output)
+		}
+	}
+
+	if r := v.ConvertToGeneralConfig().Record(); r.Link != link || r.Reality.ShortID != "6ba85179e30d4fc2" {
+		t.Errorf("expected the record to keep its secrets until Redacted, got %+v", r)
+	}
+
+	if RedactLink("not a link") == "not a link" {
+		t.Error("expected a link that can't be parsed to be masked as a whole")
+	}
+}
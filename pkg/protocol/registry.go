@@ -0,0 +1,164 @@
+package protocol
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the cores a scheme can be registered to, see Register
+const (
+	CoreXray    = "xray"
+	CoreSingbox = "singbox"
+)
+
+// Constructor returns the protocol of a config link, not parsed yet
+type Constructor func(link string) Protocol
+
+type registration struct {
+	core        string
+	constructor Constructor
+}
+
+// Registry maps the link schemes to their constructor and core.
+// The package functions (Register, Lookup, CreateProtocol) use the default registry.
+type Registry struct {
+	mu      sync.RWMutex
+	schemes map[string]registration
+}
+
+// NewRegistry returns a registry of the builtin schemes
+func NewRegistry() *Registry {
+	return &Registry{
+		schemes: map[string]registration{
+			VmessIdentifier:       {CoreXray, func(link string) Protocol { return NewVmess(link) }},
+			VlessIdentifier:       {CoreXray, func(link string) Protocol { return NewVless(link) }},
+			ShadowsocksIdentifier: {CoreXray, func(link string) Protocol { return NewShadowsocks(link) }},
+			TrojanIdentifier:      {CoreXray, func(link string) Protocol { return NewTrojan(link) }},
+			SocksIdentifier:       {CoreXray, func(link string) Protocol { return NewSocks(link) }},
+			WireguardIdentifier:   {CoreXray, func(link string) Protocol { return NewWireguard(link) }},
+			HttpIdentifier:        {CoreXray, func(link string) Protocol { return NewHttp(link) }},
+			HttpsIdentifier:       {CoreXray, func(link string) Protocol { return NewHttp(link) }},
+			Hysteria2Identifier:   {CoreSingbox, func(link string) Protocol { return NewHysteria2(link) }},
+			"hy2":                 {CoreSingbox, func(link string) Protocol { return NewHysteria2(link) }},
+			TuicIdentifier:        {CoreSingbox, func(link string) Protocol { return NewTuic(link) }},
+			HysteriaIdentifier:    {CoreSingbox, func(link string) Protocol { return NewHysteria(link) }},
+		},
+	}
+}
+
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the registry of Register, used by every command
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Register adds a link scheme to the registry: constructor makes the protocol of its links
+// and core (CoreXray or CoreSingbox) runs them.
+// The constructor returns one of the protocols of this package, so the cores can build it;
+// an alias (e.g. corp-vless://) rewrites the link into the one of the protocol it stands for.
+// Registering a scheme again replaces it. Register panics on an unknown core or a nil constructor.
+func (r *Registry) Register(scheme string, core string, constructor Constructor) {
+	if core != CoreXray && core != CoreSingbox {
+		panic(fmt.Sprintf("protocol: Register %s with unknown core %q", scheme, core))
+	}
+	if scheme == "" || constructor == nil {
+		panic("protocol: Register needs a scheme and a constructor")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// url.Parse lowercases the schemes
+	r.schemes[strings.ToLower(scheme)] = registration{core: core, constructor: constructor}
+}
+
+// Lookup returns the constructor and the core of a registered scheme
+func (r *Registry) Lookup(scheme string) (Constructor, string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reg, ok := r.schemes[strings.ToLower(scheme)]
+	return reg.constructor, reg.core, ok
+}
+
+// Schemes returns the registered schemes, sorted
+func (r *Registry) Schemes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schemes := make([]string, 0, len(r.schemes))
+	for scheme := range r.schemes {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// CreateProtocol returns the protocol of a config link (not parsed yet) from the constructor of its scheme
+func (r *Registry) CreateProtocol(configLink string) (Protocol, error) {
+	// Remove any spaces
+	configLink = strings.TrimSpace(configLink)
+
+	// Parse url
+	link, _ := SplitHopPorts(configLink)
+	uri, err := parseURL("", link)
+	if err != nil {
+		return nil, err
+	}
+
+	constructor, _, ok := r.Lookup(uri.Scheme)
+	if !ok {
+		return nil, &ParseError{Scheme: uri.Scheme, Field: "scheme", Value: uri.Scheme, Reason: ReasonUnknownScheme}
+	}
+	p := constructor(configLink)
+
+	if made := p.ConvertToGeneralConfig().OrigLink; made != configLink {
+		// An alias: the protocol only knows the rewritten link, keep the scheme of the alias on it
+		if err = setAlias(p, strings.ToLower(uri.Scheme)); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// setAlias sets the alias scheme of a protocol made by the constructor of an alias
+func setAlias(p Protocol, scheme string) error {
+	v := reflect.ValueOf(p)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("protocol: alias %s made %T, not a protocol of this package", scheme, p)
+	}
+	field := v.Elem().FieldByName("Alias")
+	if !field.IsValid() || field.Kind() != reflect.String || !field.CanSet() {
+		return fmt.Errorf("protocol: alias %s made %T, not a protocol of this package", scheme, p)
+	}
+	field.SetString(scheme)
+	return nil
+}
+
+// AliasOf returns the scheme of the alias that made a protocol, empty for the protocols of the builtin schemes
+func AliasOf(p Protocol) string {
+	v := reflect.ValueOf(p)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+	if field := v.Elem().FieldByName("Alias"); field.IsValid() && field.Kind() == reflect.String {
+		return field.String()
+	}
+	return ""
+}
+
+// Register adds a link scheme to CreateProtocol and to the automatic core (-z auto), see Registry.Register
+func Register(scheme string, core string, constructor Constructor) {
+	defaultRegistry.Register(scheme, core, constructor)
+}
+
+// Lookup returns the constructor and the core of a scheme of the default registry
+func Lookup(scheme string) (Constructor, string, bool) {
+	return defaultRegistry.Lookup(scheme)
+}
+
+// Schemes returns the schemes of the default registry, sorted
+func Schemes() []string {
+	return defaultRegistry.Schemes()
+}
@@ -0,0 +1,67 @@
+package protocol
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	r.Register("Corp-Vless", CoreSingbox, func(link string) Protocol {
+		return NewVless(VlessIdentifier + strings.TrimPrefix(link, "corp-vless"))
+	})
+
+	p, err := r.CreateProtocol("corp-vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=ws#corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if g := p.ConvertToGeneralConfig(); g.Protocol != "vless" || g.Network != "ws" {
+		t.Errorf("unexpected config %s %s", g.Protocol, g.Network)
+	}
+	if got := AliasOf(p); got != "corp-vless" {
+		t.Errorf("got scheme %q, want the alias", got)
+	}
+
+	if _, core, ok := r.Lookup("corp-vless"); !ok || core != CoreSingbox {
+		t.Errorf("got %q %v, want the sing-box core", core, ok)
+	}
+	// The default registry is not changed
+	if _, _, ok := Lookup("corp-vless"); ok {
+		t.Error("the alias leaked into the default registry")
+	}
+}
+
+func TestRegistry_Builtin(t *testing.T) {
+	r := NewRegistry()
+	for _, link := range []string{
+		"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443#vless",
+		"hy2://pw@example.com:443,20000-30000/?sni=example.com#hop",
+		"ss://YWVzLTI1Ni1nY206c2VjcmV0@example.com:443#ss",
+		"vmess://eyJhZGQiOiJleGFtcGxlLmNvbSJ9",
+		"socks://dXNlcjpwYXNz@example.com:1080#socks",
+		"wireguard://key@example.com:51820#wg",
+		"tuic://uuid:pw@example.com:443#tuic",
+		"hysteria://example.com:443?auth=pw#hy",
+		"https://example.com:443#https",
+	} {
+		p, err := r.CreateProtocol(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alias := AliasOf(p); alias != "" {
+			t.Errorf("%s: builtin scheme taken for the alias %q", link, alias)
+		}
+	}
+}
+
+func TestRegister_UnknownCore(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an unknown core")
+		}
+	}()
+	NewRegistry().Register("corp-tuic", "clash", func(link string) Protocol { return NewTuic(link) })
+}
@@ -0,0 +1,22 @@
+package protocol
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Rename sets the remark of a parsed config and returns its re-encoded link.
+// Every protocol struct (and the core structs embedding them) has a Remark field.
+func Rename(p Protocol, remark string) (string, error) {
+	v := reflect.ValueOf(p)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return "", fmt.Errorf("can't rename %T", p)
+	}
+	field := v.Elem().FieldByName("Remark")
+	if !field.IsValid() || field.Kind() != reflect.String || !field.CanSet() {
+		return "", fmt.Errorf("%T has no remark", p)
+	}
+
+	field.SetString(remark)
+	return p.ToLink(), nil
+}
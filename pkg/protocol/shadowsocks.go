@@ -0,0 +1,168 @@
+package protocol
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/naser-989/xray-knife/v3/utils"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func NewShadowsocks(link string) *Shadowsocks {
+	return &Shadowsocks{OrigLink: link}
+}
+
+func (s *Shadowsocks) Name() string {
+	return "shadowsocks"
+}
+
+func (s *Shadowsocks) Parse() error {
+	if err := checkScheme(ShadowsocksIdentifier, s.OrigLink); err != nil {
+		return err
+	}
+
+	uri, err := parseURL(ShadowsocksIdentifier, s.OrigLink)
+	if err != nil {
+		return err
+	}
+
+	secondPart := strings.SplitN(s.OrigLink[5:], "@", 2)
+
+	var decoded []byte
+	// Encryption part - b64 encoded (EncryptionType : Password)
+	if len(secondPart) > 1 {
+		decoded, err = utils.Base64Decode(secondPart[0])
+		if err != nil {
+			return &ParseError{Scheme: ShadowsocksIdentifier, Field: "userinfo", Value: secondPart[0], Reason: ReasonBadBase64, Err: err}
+		}
+	} else {
+		return &ParseError{Scheme: ShadowsocksIdentifier, Field: "userinfo", Reason: ReasonMissingCredential}
+	}
+
+	//link := "ss://" + string(decoded) + "@" + secondPart[1]
+	//uri, err := url.Parse(link)
+	//if err != nil {
+	//	return err
+	//}
+	creds := strings.SplitN(string(decoded), ":", 2)
+	if len(creds) != 2 {
+		return &ParseError{Scheme: ShadowsocksIdentifier, Field: "userinfo", Value: string(decoded), Reason: ReasonBadCredential}
+	}
+
+	s.Encryption = creds[0] // Encryption Type
+	s.Password = creds[1]   // Encryption Password
+
+	//hostPortRemark := strings.SplitN(secondPart[1], ":", 2)
+
+	s.Address, s.Port, err = splitHostPort(ShadowsocksIdentifier, uri.Host)
+	if err != nil {
+		return err
+	}
+
+	if utils.IsIPv6(s.Address) {
+		s.Address = "[" + s.Address + "]"
+	}
+
+	s.Remark, err = url.PathUnescape(uri.Fragment)
+	if err != nil {
+		s.Remark = uri.Fragment
+	}
+
+	// SIP002 plugin: "name;opt1=value1;opt2=value2"
+	if plugin := uri.Query().Get("plugin"); plugin != "" {
+		s.Plugin, s.PluginOpts, _ = strings.Cut(plugin, ";")
+	}
+
+	//s.Address = hostPortRemark[0]
+	//
+	//PortRemark := strings.SplitN(hostPortRemark[1], "#", 2)
+	//s.Port = PortRemark[0]
+
+	//remarkStr, _, _ := strings.Cut(PortRemark[1], "\n")
+
+	//s.Remark, err = url.PathUnescape(remarkStr)
+	//if err != nil {
+	//	s.Remark = remarkStr
+	//}
+
+	return nil
+}
+
+func (s *Shadowsocks) Validate() Diagnostics {
+	var d Diagnostics
+	d.CheckAddress("address", s.Address)
+	d.CheckPort("port", s.Port)
+	d.CheckShadowsocks(s.Encryption, s.Password)
+
+	switch s.Plugin {
+	case "", "obfs-local", "simple-obfs", "v2ray-plugin":
+	case "shadow-tls":
+		pluginOpts := ParsePluginOpts(s.PluginOpts)
+		version := 3
+		if v, ok := pluginOpts["version"]; ok {
+			var err error
+			if version, err = strconv.Atoi(v); err != nil || version < 1 || version > 3 {
+				d.Errorf("plugin", "invalid shadow-tls version %q", v)
+			}
+		}
+		if version > 1 && pluginOpts["password"] == "" {
+			d.Errorf("plugin", "shadow-tls v%d needs a password", version)
+		}
+		if pluginOpts["host"] == "" {
+			d.Warnf("plugin", "shadow-tls without host, the handshake server name will be empty")
+		}
+	default:
+		d.Errorf("plugin", "unknown plugin %q", s.Plugin)
+	}
+
+	return d
+}
+
+func (s *Shadowsocks) DetailsStr() string {
+	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %v\n%s: %s\n%s: %s\n",
+		color.RedString("Protocol"), s.Name(),
+		color.RedString("Remark"), s.Remark,
+		color.RedString("IP"), s.Address,
+		color.RedString("Port"), s.Port,
+		color.RedString("Encryption"), s.Encryption,
+		color.RedString("Password"), Redact(s.Password))
+
+	if s.Plugin != "" {
+		info += fmt.Sprintf("%s: %s\n%s: %s\n",
+			color.RedString("Plugin"), s.Plugin,
+			color.RedString("Plugin Options"), redactPluginOpts(s.PluginOpts))
+	}
+	return info
+}
+
+func (s *Shadowsocks) ConvertToGeneralConfig() (g GeneralConfig) {
+	g.Protocol = s.Name()
+	g.Address = s.Address
+	g.ID = s.Password
+	g.Port = s.Port
+	g.Remark = s.Remark
+	g.OrigLink = s.OrigLink
+
+	return g
+}
+
+func (s *Shadowsocks) ToLink() string {
+	// SIP002: the userinfo part is the url-safe base64 of "method:password"
+	userInfo := base64.URLEncoding.EncodeToString([]byte(s.Encryption + ":" + s.Password))
+
+	link := fmt.Sprintf("%s://%s@%s", ShadowsocksIdentifier, userInfo, JoinHostPort(s.Address, s.Port))
+	if s.Plugin != "" {
+		plugin := s.Plugin
+		if s.PluginOpts != "" {
+			plugin += ";" + s.PluginOpts
+		}
+		link += "/?" + url.Values{"plugin": {plugin}}.Encode()
+	}
+	if s.Remark != "" {
+		link += "#" + url.PathEscape(s.Remark)
+	}
+
+	return link
+}
@@ -0,0 +1,108 @@
+package protocol
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/naser-989/xray-knife/v3/utils"
+	"net/url"
+	"strings"
+)
+
+func NewSocks(link string) *Socks {
+	return &Socks{OrigLink: link}
+}
+
+func (s *Socks) Name() string {
+	return "socks"
+}
+
+func (s *Socks) Parse() error {
+	if err := checkScheme(SocksIdentifier, s.OrigLink); err != nil {
+		return err
+	}
+
+	var err error = nil
+
+	uri, err := parseURL(SocksIdentifier, s.OrigLink)
+	if err != nil {
+		return err
+	}
+	s.Remark = uri.Fragment
+	s.Address, s.Port, err = splitHostPort(SocksIdentifier, uri.Host)
+	if err != nil {
+		return err
+	}
+
+	if len(uri.User.String()) != 0 {
+		userB64, err := utils.Base64Decode(uri.User.String())
+		if err != nil {
+			return &ParseError{Scheme: SocksIdentifier, Field: "userinfo", Value: uri.User.String(), Reason: ReasonBadBase64, Err: err}
+		}
+		creds := strings.Split(string(userB64), ":")
+		if len(creds) < 2 {
+			return &ParseError{Scheme: SocksIdentifier, Field: "userinfo", Value: string(userB64), Reason: ReasonBadCredential}
+		}
+		s.Username = creds[0]
+		s.Password = creds[1]
+	}
+
+	s.OrigLink = s.OrigLink
+
+	return err
+}
+
+func (s *Socks) Validate() Diagnostics {
+	var d Diagnostics
+	d.CheckAddress("address", s.Address)
+	d.CheckPort("port", s.Port)
+	if s.Username == "" && s.Password != "" {
+		d.Warnf("username", "password without username, it won't be sent")
+	}
+
+	return d
+}
+
+func (s *Socks) DetailsStr() string {
+	copyV := *s
+
+	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %v\n",
+		color.RedString("Protocol"), s.Name(),
+		color.RedString("Remark"), copyV.Remark,
+		color.RedString("Network"), "tcp",
+		color.RedString("Address"), copyV.Address,
+		color.RedString("Port"), copyV.Port,
+	)
+
+	if len(copyV.Username) != 0 && len(copyV.Password) != 0 {
+		info += color.RedString("Username") + ": " + Redact(copyV.Username)
+		info += "\n"
+		info += color.RedString("Password") + ": " + Redact(copyV.Password)
+	}
+
+	return info
+}
+
+func (s *Socks) ConvertToGeneralConfig() (g GeneralConfig) {
+	g.Protocol = s.Name()
+	g.Address = s.Address
+	g.Port = fmt.Sprintf("%v", s.Port)
+	g.Remark = s.Remark
+
+	g.OrigLink = s.OrigLink
+
+	return g
+}
+
+func (s *Socks) ToLink() string {
+	link := SocksIdentifier + "://"
+	if s.Username != "" || s.Password != "" {
+		link += base64.URLEncoding.EncodeToString([]byte(s.Username+":"+s.Password)) + "@"
+	}
+	link += JoinHostPort(s.Address, s.Port)
+	if s.Remark != "" {
+		link += "#" + url.PathEscape(s.Remark)
+	}
+
+	return link
+}
@@ -0,0 +1,234 @@
+package protocol
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/naser-989/xray-knife/v3/utils"
+	"net/url"
+	"reflect"
+)
+
+func NewTrojan(link string) *Trojan {
+	return &Trojan{OrigLink: link}
+}
+
+func (t *Trojan) Name() string {
+	return "trojan"
+}
+
+func (t *Trojan) Parse() error {
+	if err := checkScheme(TrojanIdentifier, t.OrigLink); err != nil {
+		return err
+	}
+	uri, err := parseURL(TrojanIdentifier, t.OrigLink)
+	if err != nil {
+		return err
+	}
+
+	t.Password = uri.User.String()
+	t.Address, t.Port, err = splitHostPort(TrojanIdentifier, uri.Host)
+	if err != nil {
+		return err
+	}
+
+	if utils.IsIPv6(t.Address) {
+		t.Address = "[" + t.Address + "]"
+	}
+	// Get the type of the struct
+	structType := reflect.TypeOf(*t)
+
+	// Get the number of fields in the struct
+	numFields := structType.NumField()
+
+	// Iterate over each field of the struct
+	for i := 0; i < numFields; i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("json")
+
+		// If the query value exists for the field, set it
+		if values, ok := uri.Query()[tag]; ok {
+			value := values[0]
+			v := reflect.ValueOf(t).Elem().FieldByName(field.Name)
+
+			switch v.Type().String() {
+			case "string":
+				v.SetString(value)
+			case "int":
+				var intValue int
+				fmt.Sscanf(value, "%d", &intValue)
+				v.SetInt(int64(intValue))
+			}
+		}
+	}
+
+	t.Remark, err = url.PathUnescape(uri.Fragment)
+	if err != nil {
+		t.Remark = uri.Fragment
+	}
+
+	if t.HeaderType == "xhttp" || t.HeaderType == "http" || t.Type == "ws" || t.Type == "h2" {
+		if t.Path == "" {
+			t.Path = "/"
+		}
+	}
+
+	if t.Type == "" {
+		t.Type = "tcp"
+	}
+	if err = checkNetwork(TrojanIdentifier, "type", t.Type); err != nil {
+		return err
+	}
+	if t.Security == "" {
+		t.Security = "tls"
+	}
+	if t.TlsFingerprint == "" {
+		t.TlsFingerprint = "chrome"
+	}
+
+	return checkMux(t)
+}
+
+func (t *Trojan) Validate() Diagnostics {
+	var d Diagnostics
+	d.CheckAddress("address", t.Address)
+	d.CheckPort("port", t.Port)
+	if t.Password == "" {
+		d.Errorf("password", "password is empty")
+	}
+	if t.Flow != "" && t.Flow != "none" {
+		d.Warnf("flow", "trojan has no flow, %q is ignored", t.Flow)
+	}
+
+	d.CheckTransport(Transport{
+		Network:     t.Type,
+		Security:    t.Security,
+		PublicKey:   t.PublicKey,
+		ShortID:     t.ShortIds,
+		SNI:         t.SNI,
+		ServiceName: t.ServiceName,
+		Fingerprint: t.TlsFingerprint,
+	})
+
+	return d
+}
+
+func (t *Trojan) DetailsStr() string {
+	copyV := *t
+	if copyV.Flow == "" || copyV.Type == "grpc" {
+		copyV.Flow = "none"
+	}
+	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %v\n%s: %s\n%s: %s\n",
+		color.RedString("Protocol"), t.Name(),
+		color.RedString("Remark"), t.Remark,
+		color.RedString("Network"), t.Type,
+		color.RedString("Address"), t.Address,
+		color.RedString("Port"), t.Port,
+		color.RedString("Password"), Redact(t.Password),
+		color.RedString("Flow"), copyV.Flow,
+	)
+
+	if copyV.Type == "" {
+
+	} else if copyV.Type == "xhttp" || copyV.Type == "http" || copyV.Type == "httpupgrade" || copyV.Type == "ws" || copyV.Type == "h2" || copyV.Type == "splithttp" {
+		info += fmt.Sprintf("%s: %s\n%s: %s\n",
+			color.RedString("Host"), copyV.Host,
+			color.RedString("Path"), copyV.Path)
+	} else if copyV.Type == "kcp" {
+		info += fmt.Sprintf("%s: %s\n", color.RedString("KCP Seed"), copyV.Path)
+	} else if copyV.Type == "grpc" {
+		if copyV.ServiceName == "" {
+			copyV.ServiceName = "none"
+		}
+		info += fmt.Sprintf("%s: %s\n", color.RedString("ServiceName"), copyV.ServiceName)
+	}
+
+	if copyV.Security == "reality" {
+		info += fmt.Sprintf("%s: reality\n", color.RedString("TLS"))
+		if copyV.SpiderX == "" {
+			copyV.SpiderX = "none"
+		}
+		info += fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %s\n",
+			color.RedString("Public key"), copyV.PublicKey,
+			color.RedString("SNI"), copyV.SNI,
+			color.RedString("ShortID"), Redact(copyV.ShortIds),
+			color.RedString("SpiderX"), copyV.SpiderX,
+			color.RedString("Fingerprint"), copyV.TlsFingerprint,
+		)
+	} else if copyV.Security == "tls" {
+		info += fmt.Sprintf("%s: tls\n", color.RedString("TLS"))
+		if len(copyV.SNI) == 0 {
+			if copyV.Host != "" {
+				copyV.SNI = copyV.Host
+			} else {
+				copyV.SNI = "none"
+			}
+		}
+		if len(copyV.ALPN) == 0 {
+			copyV.ALPN = "none"
+		}
+		if copyV.TlsFingerprint == "" {
+			copyV.TlsFingerprint = "none"
+		}
+		info += fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n",
+			color.RedString("SNI"), copyV.SNI,
+			color.RedString("ALPN"), copyV.ALPN,
+			color.RedString("Fingerprint"), copyV.TlsFingerprint)
+
+		if t.AllowInsecure != "" {
+			info += fmt.Sprintf("%s: %v\n",
+				color.RedString("Insecure"), t.AllowInsecure)
+		}
+	} else {
+		info += fmt.Sprintf("%s: none\n", color.RedString("TLS"))
+	}
+	info += muxDetails(t)
+	return info
+}
+
+func (t *Trojan) ConvertToGeneralConfig() (g GeneralConfig) {
+	g.Protocol = t.Name()
+	g.Address = t.Address
+	g.Host = t.Host
+	g.ID = t.Password
+	g.Path = t.Path
+	g.Port = t.Port
+	g.Remark = t.Remark
+	g.SNI = t.SNI
+	g.ALPN = t.ALPN
+	if t.Security == "" {
+		g.TLS = "none"
+	} else {
+		g.TLS = t.Security
+	}
+	g.TlsFingerprint = t.TlsFingerprint
+	g.ServiceName = t.ServiceName
+	g.Mode = t.Mode
+	g.Type = t.Type
+	g.Network = t.Type
+	if g.Network == "" {
+		g.Network = "tcp"
+	}
+	g.HeaderType = t.HeaderType
+	g.Flow = t.Flow
+	g.PublicKey = t.PublicKey
+	g.ShortID = t.ShortIds
+	g.SpiderX = t.SpiderX
+	g.AllowInsecure = t.AllowInsecure
+	g.OrigLink = t.OrigLink
+
+	return g
+}
+
+func (t *Trojan) ToLink() string {
+	query := BuildQuery(t, "add", "port")
+
+	link := fmt.Sprintf("%s://%s@%s", TrojanIdentifier, t.Password, JoinHostPort(t.Address, t.Port))
+	if len(query) != 0 {
+		link += "?" + query.Encode()
+	}
+	if t.Remark != "" {
+		link += "#" + url.PathEscape(t.Remark)
+	}
+
+	return link
+}
@@ -0,0 +1,166 @@
+package protocol
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"net/url"
+	"reflect"
+)
+
+func NewTuic(link string) *Tuic {
+	return &Tuic{OrigLink: link}
+}
+
+func (t *Tuic) Name() string {
+	return TuicIdentifier
+}
+
+func (t *Tuic) Parse() error {
+	if err := checkScheme(TuicIdentifier, t.OrigLink); err != nil {
+		return err
+	}
+	uri, err := parseURL(TuicIdentifier, t.OrigLink)
+	if err != nil {
+		return err
+	}
+
+	if uri.User == nil {
+		return &ParseError{Scheme: TuicIdentifier, Field: "uuid", Reason: ReasonMissingCredential}
+	}
+	t.UUID = uri.User.Username()
+	t.Password, _ = uri.User.Password()
+
+	t.Address, t.Port, err = splitHostPort(TuicIdentifier, uri.Host)
+	if err != nil {
+		return err
+	}
+
+	// Get the type of the struct
+	ty := reflect.TypeOf(*t)
+
+	// Get the number of fields in the struct
+	numFields := ty.NumField()
+
+	// Iterate over each field of the struct
+	for i := 0; i < numFields; i++ {
+		field := ty.Field(i)
+		tag := field.Tag.Get("json")
+
+		// If the query value exists for the field, set it
+		if values, ok := uri.Query()[tag]; ok {
+			value := values[0]
+			v := reflect.ValueOf(t).Elem().FieldByName(field.Name)
+
+			switch v.Type().String() {
+			case "string":
+				v.SetString(value)
+			case "int":
+				var intValue int
+				fmt.Sscanf(value, "%d", &intValue)
+				v.SetInt(int64(intValue))
+			}
+		}
+	}
+
+	// Some clients write "insecure" instead of "allow_insecure"
+	if t.Insecure == "" {
+		t.Insecure = uri.Query().Get("insecure")
+	}
+
+	t.Remark, err = url.PathUnescape(uri.Fragment)
+	if err != nil {
+		t.Remark = uri.Fragment
+	}
+
+	return nil
+}
+
+func (t *Tuic) Validate() Diagnostics {
+	var d Diagnostics
+	d.CheckAddress("address", t.Address)
+	d.CheckPort("port", t.Port)
+	if !IsUUID(t.UUID) {
+		d.Errorf("uuid", "invalid UUID %q", t.UUID)
+	}
+	if t.Password == "" {
+		d.Errorf("password", "password is empty")
+	}
+
+	switch t.CongestionControl {
+	case "", "cubic", "new_reno", "bbr":
+	default:
+		d.Errorf("congestion_control", "unknown congestion control %q", t.CongestionControl)
+	}
+	switch t.UDPRelayMode {
+	case "", "native", "quic":
+	default:
+		d.Errorf("udp_relay_mode", "unknown udp relay mode %q", t.UDPRelayMode)
+	}
+
+	return d
+}
+
+func (t *Tuic) DetailsStr() string {
+	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %s\n",
+		color.RedString("Protocol"), t.Name(),
+		color.RedString("Remark"), t.Remark,
+		color.RedString("Address"), t.Address,
+		color.RedString("Port"), t.Port,
+		color.RedString("UUID"), Redact(t.UUID),
+		color.RedString("Password"), Redact(t.Password))
+
+	if t.CongestionControl != "" {
+		info += fmt.Sprintf("%s: %s\n", color.RedString("Congestion Control"), t.CongestionControl)
+	}
+	if t.UDPRelayMode != "" {
+		info += fmt.Sprintf("%s: %s\n", color.RedString("UDP Relay Mode"), t.UDPRelayMode)
+	}
+
+	if len(t.SNI) == 0 {
+		info += fmt.Sprintf("%s: %v\n", color.RedString("SNI"), "none")
+	} else {
+		info += fmt.Sprintf("%s: %v\n", color.RedString("SNI"), t.SNI)
+	}
+
+	if len(t.ALPN) == 0 {
+		info += fmt.Sprintf("%s: %v\n", color.RedString("ALPN"), "none")
+	} else {
+		info += fmt.Sprintf("%s: %v\n", color.RedString("ALPN"), t.ALPN)
+	}
+
+	if t.Insecure != "" {
+		info += fmt.Sprintf("%s: %v\n", color.RedString("Insecure"), t.Insecure)
+	}
+
+	return info
+}
+
+func (t *Tuic) ConvertToGeneralConfig() (g GeneralConfig) {
+	g.Protocol = t.Name()
+	g.Address = t.Address
+	g.Port = t.Port
+	g.ID = t.UUID
+	g.Remark = t.Remark
+	g.TLS = "tls"
+	g.SNI = t.SNI
+	g.ALPN = t.ALPN
+	g.AllowInsecure = t.Insecure
+
+	g.OrigLink = t.OrigLink
+
+	return g
+}
+
+func (t *Tuic) ToLink() string {
+	query := BuildQuery(t)
+
+	link := fmt.Sprintf("%s://%s@%s", TuicIdentifier, url.UserPassword(t.UUID, t.Password).String(), JoinHostPort(t.Address, t.Port))
+	if len(query) != 0 {
+		link += "?" + query.Encode()
+	}
+	if t.Remark != "" {
+		link += "#" + url.PathEscape(t.Remark)
+	}
+
+	return link
+}
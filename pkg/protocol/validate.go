@@ -0,0 +1,289 @@
+package protocol
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // The core will refuse the config or it can't work
+	SeverityWarning Severity = "warning" // The config works but probably not the way it was meant to
+)
+
+// Diagnostic is a single finding of a Validate method
+type Diagnostic struct {
+	Severity Severity
+	Field    string // Link parameter the finding is about (e.g. "pbk"), empty for the whole config
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Field == "" {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Severity, d.Field, d.Message)
+}
+
+// Diagnostics collects the findings of a Validate method
+type Diagnostics []Diagnostic
+
+func (d *Diagnostics) Errorf(field string, format string, a ...interface{}) {
+	*d = append(*d, Diagnostic{Severity: SeverityError, Field: field, Message: fmt.Sprintf(format, a...)})
+}
+
+func (d *Diagnostics) Warnf(field string, format string, a ...interface{}) {
+	*d = append(*d, Diagnostic{Severity: SeverityWarning, Field: field, Message: fmt.Sprintf(format, a...)})
+}
+
+// HasErrors reports whether any of the diagnostics is an error
+func (d Diagnostics) HasErrors() bool {
+	for _, diag := range d {
+		if diag.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Escalate turns the warnings about field into errors, for the cores that reject what the others accept
+func (d Diagnostics) Escalate(field string) Diagnostics {
+	for i := range d {
+		if d[i].Field == field {
+			d[i].Severity = SeverityError
+		}
+	}
+	return d
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Fingerprints known by both xray and sing-box uTLS
+var fingerprints = []string{"chrome", "firefox", "safari", "ios", "android", "edge", "360", "qq", "random", "randomized"}
+
+// Networks of the V2Ray style links (type / net parameter)
+var networks = []string{"tcp", "raw", "ws", "grpc", "http", "h2", "httpupgrade", "splithttp", "xhttp", "quic", "kcp", "mkcp"}
+
+// CheckAddress reports an empty or malformed server address
+func (d *Diagnostics) CheckAddress(field string, address string) {
+	address = strings.Trim(address, "[]")
+	if address == "" {
+		d.Errorf(field, "server address is empty")
+		return
+	}
+	if strings.ContainsAny(address, " /?#@") {
+		d.Errorf(field, "invalid server address %q", address)
+	}
+}
+
+// CheckPort reports a port that isn't a number between 1 and 65535
+func (d *Diagnostics) CheckPort(field string, port string) {
+	if port == "" {
+		d.Errorf(field, "port is empty")
+		return
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		d.Errorf(field, "invalid port %q", port)
+	}
+}
+
+// IsUUID reports whether id is a UUID in its canonical form
+func IsUUID(id string) bool {
+	return uuidPattern.MatchString(id)
+}
+
+// CheckUUID reports an id that isn't a UUID.
+// xray maps short strings (up to 30 bytes) to a UUID, so those are only a warning.
+func (d *Diagnostics) CheckUUID(field string, id string) {
+	switch {
+	case id == "":
+		d.Errorf(field, "user id is empty")
+	case IsUUID(id):
+	case len(id) <= 30:
+		d.Warnf(field, "%q is not a UUID, only xray accepts it (mapped to a UUIDv5)", id)
+	default:
+		d.Errorf(field, "invalid UUID %q", id)
+	}
+}
+
+// CheckFingerprint reports a TLS fingerprint that uTLS doesn't know
+func (d *Diagnostics) CheckFingerprint(field string, fp string) {
+	if fp != "" && !contains(fingerprints, strings.ToLower(fp)) {
+		d.Errorf(field, "unknown TLS fingerprint %q (known: %s)", fp, strings.Join(fingerprints, ", "))
+	}
+}
+
+// CheckBase64Key reports a key that isn't a base64 encoded 32 bytes key (WireGuard, REALITY)
+func (d *Diagnostics) CheckBase64Key(field string, key string, encodings ...*base64.Encoding) {
+	if key == "" {
+		d.Errorf(field, "key is empty")
+		return
+	}
+	for _, encoding := range encodings {
+		if decoded, err := encoding.DecodeString(key); err == nil && len(decoded) == 32 {
+			return
+		}
+	}
+	d.Errorf(field, "invalid key %q, expected 32 base64 encoded bytes", key)
+}
+
+// CheckHostPort reports an endpoint that isn't a host:port pair
+func (d *Diagnostics) CheckHostPort(field string, endpoint string) {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		d.Errorf(field, "invalid endpoint %q, expected host:port", endpoint)
+		return
+	}
+	d.CheckAddress(field, host)
+	d.CheckPort(field, port)
+}
+
+// Transport is the transport and security of a V2Ray style link (vmess, vless, trojan)
+type Transport struct {
+	Network     string
+	Security    string
+	Flow        string
+	PublicKey   string
+	ShortID     string
+	SNI         string
+	ServiceName string
+	Fingerprint string
+
+	// Link parameters holding the network, the security and the grpc service name,
+	// "type", "security" and "serviceName" when empty
+	NetworkField     string
+	SecurityField    string
+	ServiceNameField string
+}
+
+// CheckTransport reports transport and security combinations that the cores reject or that can't work
+func (d *Diagnostics) CheckTransport(t Transport) {
+	networkField, securityField, serviceNameField := t.NetworkField, t.SecurityField, t.ServiceNameField
+	if networkField == "" {
+		networkField = "type"
+	}
+	if securityField == "" {
+		securityField = "security"
+	}
+	if serviceNameField == "" {
+		serviceNameField = "serviceName"
+	}
+
+	if t.Network != "" && !contains(networks, t.Network) {
+		d.Errorf(networkField, "unknown transport %q", t.Network)
+	}
+	if t.Network == "grpc" && t.ServiceName == "" {
+		d.Warnf(serviceNameField, "grpc transport without serviceName, most servers expect one")
+	}
+
+	switch t.Security {
+	case "", "none", "tls":
+	case "reality":
+		if t.PublicKey == "" {
+			d.Errorf("pbk", "REALITY needs the server public key")
+		} else {
+			d.CheckBase64Key("pbk", t.PublicKey, base64.RawURLEncoding)
+		}
+		if _, err := hex.DecodeString(t.ShortID); err != nil || len(t.ShortID) > 16 {
+			d.Errorf("sid", "invalid REALITY short id %q, expected up to 16 hex digits", t.ShortID)
+		}
+		if t.SNI == "" {
+			d.Warnf("sni", "REALITY without sni, the handshake will fail on most servers")
+		}
+	default:
+		d.Errorf(securityField, "unknown security %q", t.Security)
+	}
+	if t.Security == "tls" || t.Security == "reality" {
+		d.CheckFingerprint("fp", t.Fingerprint)
+	}
+
+	switch t.Flow {
+	case "", "none":
+	case "xtls-rprx-vision", "xtls-rprx-vision-udp443":
+		if t.Network != "" && t.Network != "tcp" && t.Network != "raw" {
+			d.Errorf("flow", "%s only works on the tcp transport, not %s", t.Flow, t.Network)
+		}
+		if t.Security != "tls" && t.Security != "reality" {
+			d.Errorf("flow", "%s needs tls or reality security", t.Flow)
+		}
+	default:
+		d.Errorf("flow", "unknown flow %q", t.Flow)
+	}
+}
+
+// Key lengths of the Shadowsocks 2022 methods, the password is the base64 encoded key
+var shadowsocks2022 = map[string]int{
+	"2022-blake3-aes-128-gcm":       16,
+	"2022-blake3-aes-256-gcm":       32,
+	"2022-blake3-chacha20-poly1305": 32,
+}
+
+var shadowsocksAEAD = []string{
+	"aes-128-gcm", "aes-192-gcm", "aes-256-gcm",
+	"chacha20-ietf-poly1305", "chacha20-poly1305", "xchacha20-ietf-poly1305", "xchacha20-poly1305",
+	"none", "plain",
+}
+
+// CheckShadowsocks reports an unknown method or a password that doesn't fit the method
+func (d *Diagnostics) CheckShadowsocks(method string, password string) {
+	if method == "" {
+		d.Errorf("method", "method is empty")
+		return
+	}
+	if password == "" && method != "none" && method != "plain" {
+		d.Errorf("password", "password is empty")
+		return
+	}
+
+	if keyLen, ok := shadowsocks2022[method]; ok {
+		// Multi-user servers take "serverKey:userKey"
+		for _, key := range strings.Split(password, ":") {
+			if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != keyLen {
+				d.Errorf("password", "%s needs a base64 encoded %d bytes key", method, keyLen)
+				return
+			}
+		}
+		return
+	}
+	if !contains(shadowsocksAEAD, method) {
+		d.Warnf("method", "%q is not an AEAD method, xray doesn't support it and most servers dropped it", method)
+	}
+}
+
+// CheckReserved reports WireGuard reserved bytes that aren't three comma separated bytes
+func (d *Diagnostics) CheckReserved(field string, reserved string) {
+	if reserved == "" {
+		return
+	}
+	values := strings.Split(reserved, ",")
+	if len(values) != 3 {
+		d.Errorf(field, "expected 3 reserved bytes, got %d", len(values))
+		return
+	}
+	for _, v := range values {
+		if _, err := strconv.ParseUint(strings.TrimSpace(v), 10, 8); err != nil {
+			d.Errorf(field, "invalid reserved byte %q", v)
+			return
+		}
+	}
+}
+
+// CheckPrefixes reports a comma separated list of local addresses that aren't IPs or CIDRs
+func (d *Diagnostics) CheckPrefixes(field string, addresses string) {
+	if addresses == "" {
+		d.Errorf(field, "local address is empty")
+		return
+	}
+	for _, address := range strings.Split(addresses, ",") {
+		address = strings.TrimSpace(address)
+		if _, _, err := net.ParseCIDR(address); err != nil && net.ParseIP(address) == nil {
+			d.Errorf(field, "invalid local address %q", address)
+		}
+	}
+}
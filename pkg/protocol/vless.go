@@ -0,0 +1,238 @@
+package protocol
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/naser-989/xray-knife/v3/utils"
+	"net/url"
+	"reflect"
+)
+
+func NewVless(link string) *Vless {
+	return &Vless{OrigLink: link}
+}
+
+func (v *Vless) Name() string {
+	return "vless"
+}
+
+func (v *Vless) Parse() error {
+	if err := checkScheme(VlessIdentifier, v.OrigLink); err != nil {
+		return err
+	}
+
+	uri, err := parseURL(VlessIdentifier, v.OrigLink)
+	if err != nil {
+		return err
+	}
+
+	v.ID = uri.User.String()
+
+	v.Address, v.Port, err = splitHostPort(VlessIdentifier, uri.Host)
+	if err != nil {
+		return err
+	}
+
+	if utils.IsIPv6(v.Address) {
+		v.Address = "[" + v.Address + "]"
+	}
+
+	// Get the type of the struct
+	t := reflect.TypeOf(*v)
+
+	// Get the number of fields in the struct
+	numFields := t.NumField()
+
+	// Iterate over each field of the struct
+	for i := 0; i < numFields; i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		// If the query value exists for the field, set it
+		if values, ok := uri.Query()[tag]; ok {
+			value := values[0]
+			v := reflect.ValueOf(v).Elem().FieldByName(field.Name)
+
+			switch v.Type().String() {
+			case "string":
+				v.SetString(value)
+			case "int":
+				var intValue int
+				fmt.Sscanf(value, "%d", &intValue)
+				v.SetInt(int64(intValue))
+			}
+		}
+	}
+
+	v.Remark, err = url.PathUnescape(uri.Fragment)
+	if err != nil {
+		v.Remark = uri.Fragment
+	}
+	//portUint, err := strconv.ParseUint(address[1], 10, 16)
+	//if err != nil {
+	//	fmt.Fprintf(os.Stderr, "%v", err)
+	//	os.Exit(1)
+	//}
+	//v.Port = uint16(portUint)
+
+	if err = checkNetwork(VlessIdentifier, "type", v.Type); err != nil {
+		return err
+	}
+
+	if v.HeaderType == "http" || v.Type == "ws" || v.Type == "h2" {
+		if v.Path == "" {
+			v.Path = "/"
+		}
+	}
+
+	return checkMux(v)
+}
+
+func (v *Vless) Validate() Diagnostics {
+	var d Diagnostics
+	d.CheckAddress("address", v.Address)
+	d.CheckPort("port", v.Port)
+	d.CheckUUID("id", v.ID)
+
+	if v.Encryption != "" && v.Encryption != "none" {
+		d.Errorf("encryption", "vless only supports encryption=none, got %q", v.Encryption)
+	}
+
+	d.CheckTransport(Transport{
+		Network:     v.Type,
+		Security:    v.Security,
+		Flow:        v.Flow,
+		PublicKey:   v.PublicKey,
+		ShortID:     v.ShortIds,
+		SNI:         v.SNI,
+		ServiceName: v.ServiceName,
+		Fingerprint: v.TlsFingerprint,
+	})
+
+	return d
+}
+
+func (v *Vless) DetailsStr() string {
+	copyV := *v
+	if copyV.Flow == "" || copyV.Type == "grpc" {
+		copyV.Flow = "none"
+	}
+	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %v\n%s: %s\n%s: %s\n",
+		color.RedString("Protocol"), v.Name(),
+		color.RedString("Remark"), v.Remark,
+		color.RedString("Network"), v.Type,
+		color.RedString("Address"), v.Address,
+		color.RedString("Port"), v.Port,
+		color.RedString("UUID"), Redact(v.ID),
+		color.RedString("Flow"), copyV.Flow)
+	if copyV.Type == "" {
+
+	} else if copyV.HeaderType == "xhttp" || copyV.HeaderType == "http" || copyV.Type == "httpupgrade" || copyV.Type == "ws" || copyV.Type == "h2" || copyV.Type == "splithttp" {
+		info += fmt.Sprintf("%s: %s\n%s: %s\n",
+			color.RedString("Host"), copyV.Host,
+			color.RedString("Path"), copyV.Path)
+	} else if copyV.Type == "kcp" {
+		info += fmt.Sprintf("%s: %s\n", color.RedString("KCP Seed"), copyV.Path)
+	} else if copyV.Type == "grpc" {
+		if copyV.ServiceName == "" {
+			copyV.ServiceName = "none"
+		}
+		if copyV.Authority == "" {
+			copyV.Authority = "none"
+		}
+		info += fmt.Sprintf("%s: %s\n%s: %s\n",
+			color.RedString("ServiceName"), copyV.ServiceName,
+			color.RedString("Authority"), copyV.Authority)
+	}
+
+	if copyV.Security == "reality" {
+		info += fmt.Sprintf("%s: reality\n", color.RedString("TLS"))
+		if copyV.SpiderX == "" {
+			copyV.SpiderX = "none"
+		}
+		info += fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %s\n",
+			color.RedString("Public key"), copyV.PublicKey,
+			color.RedString("SNI"), copyV.SNI,
+			color.RedString("ShortID"), Redact(copyV.ShortIds),
+			color.RedString("SpiderX"), copyV.SpiderX,
+			color.RedString("Fingerprint"), copyV.TlsFingerprint,
+		)
+	} else if copyV.Security == "tls" {
+		info += fmt.Sprintf("%s: tls\n", color.RedString("TLS"))
+		if len(copyV.SNI) == 0 {
+			if copyV.Host != "" {
+				copyV.SNI = copyV.Host
+			} else {
+				copyV.SNI = "none"
+			}
+		}
+		if len(copyV.ALPN) == 0 {
+			copyV.ALPN = "none"
+		}
+		if copyV.TlsFingerprint == "" {
+			copyV.TlsFingerprint = "none"
+		}
+		info += fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n",
+			color.RedString("SNI"), copyV.SNI,
+			color.RedString("ALPN"), copyV.ALPN,
+			color.RedString("Fingerprint"), copyV.TlsFingerprint)
+
+		if v.AllowInsecure != "" {
+			info += fmt.Sprintf("%s: %v\n",
+				color.RedString("Insecure"), v.AllowInsecure)
+		}
+	} else {
+		info += fmt.Sprintf("%s: none\n", color.RedString("TLS"))
+	}
+	info += muxDetails(v)
+	return info
+}
+
+func (v *Vless) ConvertToGeneralConfig() (g GeneralConfig) {
+	g.Protocol = v.Name()
+	g.Address = v.Address
+	g.Host = v.Host
+	g.ID = v.ID
+	g.Path = v.Path
+	g.Port = v.Port
+	g.Remark = v.Remark
+	if v.Security == "" {
+		g.TLS = "none"
+	} else {
+		g.TLS = v.Security
+	}
+	g.SNI = v.SNI
+	g.ALPN = v.ALPN
+	g.TlsFingerprint = v.TlsFingerprint
+	g.Authority = v.Authority
+	g.ServiceName = v.ServiceName
+	g.Mode = v.Mode
+	g.Type = v.Type
+	g.Network = v.Type
+	if g.Network == "" {
+		g.Network = "tcp"
+	}
+	g.HeaderType = v.HeaderType
+	g.Flow = v.Flow
+	g.PublicKey = v.PublicKey
+	g.ShortID = v.ShortIds
+	g.SpiderX = v.SpiderX
+	g.AllowInsecure = v.AllowInsecure
+	g.OrigLink = v.OrigLink
+
+	return g
+}
+
+func (v *Vless) ToLink() string {
+	query := BuildQuery(v, "id", "add", "port", "ps")
+
+	link := fmt.Sprintf("%s://%s@%s", VlessIdentifier, v.ID, JoinHostPort(v.Address, v.Port))
+	if len(query) != 0 {
+		link += "?" + query.Encode()
+	}
+	if v.Remark != "" {
+		link += "#" + url.PathEscape(v.Remark)
+	}
+
+	return link
+}
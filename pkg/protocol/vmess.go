@@ -0,0 +1,279 @@
+package protocol
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/naser-989/xray-knife/v3/utils"
+	"strings"
+)
+
+func NewVmess(link string) *Vmess {
+	return &Vmess{OrigLink: link}
+}
+
+func (v *Vmess) Name() string {
+	return "vmess"
+}
+
+func method1(v *Vmess, link string) error {
+	b64encoded := link[8:]
+	decoded, err := utils.Base64Decode(b64encoded)
+	if err != nil {
+		return &ParseError{Scheme: VmessIdentifier, Field: "payload", Value: b64encoded, Reason: ReasonBadBase64, Err: err}
+	}
+	if err = json.Unmarshal(decoded, v); err != nil {
+		return &ParseError{Scheme: VmessIdentifier, Field: "payload", Reason: ReasonBadJSON, Err: err}
+	}
+
+	if utils.IsIPv6(v.Address) {
+		v.Address = "[" + v.Address + "]"
+	}
+	return nil
+}
+
+// Example:
+// vmess://YXV0bzpjYmI0OTM1OC00NGQxLTQ4MmYtYWExNC02ODA3NzNlNWNjMzdAc25hcHBmb29kLmlyOjQ0Mw?remarks=sth&obfsParam=huhierg.com&path=/&obfs=websocket&tls=1&peer=gdfgreg.com&alterId=0
+func method2(v *Vmess, link string) error {
+	uri, err := parseURL(VmessIdentifier, link)
+	if err != nil {
+		return err
+	}
+	decoded, err := utils.Base64Decode(uri.Host)
+	if err != nil {
+		return &ParseError{Scheme: VmessIdentifier, Field: "payload", Value: uri.Host, Reason: ReasonBadBase64, Err: err}
+	}
+	link = VmessIdentifier + "://" + string(decoded) + "?" + uri.RawQuery
+
+	uri, err = parseURL(VmessIdentifier, link)
+	if err != nil {
+		return err
+	}
+
+	v.Security = uri.User.Username()
+	v.ID, _ = uri.User.Password()
+
+	v.Address, v.Port, err = splitHostPort(VmessIdentifier, uri.Host)
+	if err != nil {
+		return err
+	}
+
+	if utils.IsIPv6(v.Address) {
+		v.Address = "[" + v.Address + "]"
+	}
+	//parseUint, err := strconv.ParseUint(suhp[2], 10, 16)
+	//if err != nil {
+	//	return err
+	//}
+
+	v.Aid = "0"
+
+	queryValues := uri.Query()
+	if value := queryValues.Get("remarks"); value != "" {
+		v.Remark = value
+	}
+
+	if value := queryValues.Get("path"); value != "" {
+		v.Path = value
+	}
+
+	if value := queryValues.Get("tls"); value == "1" {
+		v.TLS = "tls"
+	}
+
+	if value := queryValues.Get("obfs"); value != "" {
+		switch value {
+		case "websocket":
+			v.Network = "ws"
+			v.Type = "none"
+		case "none":
+			v.Network = "tcp"
+			v.Type = "none"
+		}
+	}
+	if value := queryValues.Get("obfsParam"); value != "" {
+		v.Host = value
+	}
+	if value := queryValues.Get("peer"); value != "" {
+		v.SNI = value
+	} else {
+		if v.TLS == "tls" {
+			v.SNI = v.Host
+		}
+	}
+
+	return nil
+}
+
+//func method3(v *Vmess, link string) error {
+//
+//}
+
+func (v *Vmess) Parse() error {
+	if err := checkScheme(VmessIdentifier, v.OrigLink); err != nil {
+		return err
+	}
+
+	var err error = nil
+
+	if err = method1(v, v.OrigLink); err != nil {
+		// The JSON error of a link that decodes is more telling than the error of the other format
+		jsonErr := err
+		if err = method2(v, v.OrigLink); err != nil {
+			if ReasonOf(jsonErr) == ReasonBadJSON {
+				return jsonErr
+			}
+			return err
+		}
+	}
+
+	if err = checkNetwork(VmessIdentifier, "net", v.Network); err != nil {
+		return err
+	}
+
+	if v.Type == "xhttp" || v.Type == "http" || v.Network == "ws" || v.Network == "h2" {
+		if v.Path == "" {
+			v.Path = "/"
+		}
+	}
+
+	return checkMux(v)
+}
+
+func (v *Vmess) Validate() Diagnostics {
+	var d Diagnostics
+	d.CheckAddress("add", v.Address)
+	d.CheckPort("port", fmt.Sprintf("%v", v.Port))
+	d.CheckUUID("id", v.ID)
+
+	switch v.Security {
+	case "", "auto", "aes-128-gcm", "chacha20-poly1305", "none", "zero":
+	default:
+		d.Errorf("scy", "unknown vmess security %q", v.Security)
+	}
+	if aid := fmt.Sprintf("%v", v.Aid); aid != "0" && aid != "" && v.Aid != nil {
+		d.Warnf("aid", "alterId %s is deprecated, servers only accept VMessAEAD (aid=0)", aid)
+	}
+
+	// Vmess links keep the grpc service name in path
+	d.CheckTransport(Transport{
+		Network:          v.Network,
+		Security:         v.TLS,
+		SNI:              v.SNI,
+		ServiceName:      v.Path,
+		Fingerprint:      v.TlsFingerprint,
+		NetworkField:     "net",
+		SecurityField:    "tls",
+		ServiceNameField: "path",
+	})
+
+	return d
+}
+
+func (v *Vmess) DetailsStr() string {
+	copyV := *v
+	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %v\n%s: %s\n",
+		color.RedString("Protocol"), v.Name(),
+		color.RedString("Remark"), copyV.Remark,
+		color.RedString("Network"), copyV.Network,
+		color.RedString("Address"), copyV.Address,
+		color.RedString("Port"), copyV.Port,
+		color.RedString("UUID"), Redact(copyV.ID))
+
+	if copyV.Network == "" {
+
+	} else if copyV.Type == "xhttp" || copyV.Type == "http" || copyV.Network == "httpupgrade" || copyV.Network == "ws" || copyV.Network == "h2" {
+		if copyV.Type == "" {
+			copyV.Type = "none"
+		}
+		if copyV.Host == "" {
+			copyV.Host = "none"
+		}
+		if copyV.Path == "" {
+			copyV.Path = "none"
+		}
+
+		info += fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n",
+			color.RedString("Type"), copyV.Type,
+			color.RedString("Host"), copyV.Host,
+			color.RedString("Path"), copyV.Path)
+	} else if copyV.Network == "kcp" {
+		info += fmt.Sprintf("%s: %s\n", color.RedString("KCP Seed"), copyV.Path)
+	} else if copyV.Network == "grpc" {
+		if copyV.Host == "" {
+			copyV.Host = "none"
+		}
+		info += fmt.Sprintf("%s: %s\n%s: %s\n",
+			color.RedString("ServiceName"), copyV.Path,
+			color.RedString("Authority"), copyV.Host)
+	}
+
+	if len(copyV.TLS) != 0 && copyV.TLS != "none" {
+		if len(copyV.SNI) == 0 {
+			if copyV.Host != "" {
+				copyV.SNI = copyV.Host
+			} else {
+				copyV.SNI = "none"
+			}
+		}
+		if len(copyV.ALPN) == 0 {
+			copyV.ALPN = "none"
+		}
+		if len(copyV.TlsFingerprint) == 0 {
+			copyV.TlsFingerprint = "none"
+		}
+		info += fmt.Sprintf("%s: tls\n%s: %s\n%s: %s\n%s: %s\n",
+			color.RedString("TLS"),
+			color.RedString("SNI"), copyV.SNI,
+			color.RedString("ALPN"), copyV.ALPN,
+			color.RedString("Fingerprint"), copyV.TlsFingerprint)
+
+		if v.AllowInsecure != "" {
+			info += fmt.Sprintf("%s: %v\n",
+				color.RedString("Insecure"), v.AllowInsecure)
+		}
+	}
+	info += muxDetails(v)
+	return info
+}
+
+func (v *Vmess) ConvertToGeneralConfig() (g GeneralConfig) {
+	g.Protocol = v.Name()
+	g.Address = v.Address
+	g.Aid = fmt.Sprintf("%v", v.Aid)
+	g.Host = v.Host
+	g.ID = v.ID
+	g.Network = v.Network
+	g.Path = v.Path
+	g.Port = fmt.Sprintf("%v", v.Port)
+	g.Remark = v.Remark
+	if v.TLS == "" {
+		g.TLS = "none"
+	} else {
+		g.TLS = v.TLS
+	}
+	g.TLS = v.TLS
+	g.SNI = v.SNI
+	g.ALPN = v.ALPN
+	g.TlsFingerprint = v.TlsFingerprint
+	g.Type = v.Type
+	g.HeaderType = v.Type
+	if v.AllowInsecure != nil {
+		g.AllowInsecure = fmt.Sprintf("%v", v.AllowInsecure)
+	}
+	g.OrigLink = v.OrigLink
+
+	return g
+}
+
+func (v *Vmess) ToLink() string {
+	copyV := *v
+	copyV.Address = strings.Trim(copyV.Address, "[]")
+	if copyV.Version == nil {
+		copyV.Version = "2"
+	}
+
+	b, _ := json.Marshal(copyV)
+	return VmessIdentifier + "://" + base64.StdEncoding.EncodeToString(b)
+}
@@ -0,0 +1,132 @@
+package protocol
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/fatih/color"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+func NewWireguard(link string) *Wireguard {
+	return &Wireguard{OrigLink: link}
+}
+
+func (w *Wireguard) Name() string {
+	return "wireguard"
+}
+
+func (w *Wireguard) Parse() error {
+	if err := checkScheme(WireguardIdentifier, w.OrigLink); err != nil {
+		return err
+	}
+
+	uri, err := parseURL(WireguardIdentifier, w.OrigLink)
+	if err != nil {
+		return err
+	}
+
+	unescapedSecretKey, err0 := url.PathUnescape(uri.User.String())
+	if err0 != nil {
+		return &ParseError{Scheme: WireguardIdentifier, Field: "secret key", Reason: ReasonBadCredential, Err: err0}
+	}
+
+	w.SecretKey = unescapedSecretKey
+
+	w.Endpoint = uri.Host
+
+	// Get the type of the struct
+	t := reflect.TypeOf(*w)
+
+	// Get the number of fields in the struct
+	numFields := t.NumField()
+
+	// Iterate over each field of the struct
+	for i := 0; i < numFields; i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		// If the query value exists for the field, set it
+		if values, ok := uri.Query()[tag]; ok {
+			value := values[0]
+			v := reflect.ValueOf(w).Elem().FieldByName(field.Name)
+
+			switch v.Type().String() {
+			case "string":
+				v.SetString(value)
+			case "int32":
+				intValue, err := strconv.ParseInt(value, 10, 32)
+				if err != nil {
+					return &ParseError{Scheme: WireguardIdentifier, Field: tag, Value: value, Reason: ReasonBadValue, Err: err}
+				}
+				v.SetInt(intValue)
+			}
+		}
+	}
+
+	w.Remark, err = url.PathUnescape(uri.Fragment)
+	if err != nil {
+		w.Remark = uri.Fragment
+	}
+
+	return nil
+}
+
+func (w *Wireguard) Validate() Diagnostics {
+	var d Diagnostics
+	d.CheckHostPort("endpoint", w.Endpoint)
+	d.CheckBase64Key("secretkey", w.SecretKey, base64.StdEncoding)
+	d.CheckBase64Key("publickey", w.PublicKey, base64.StdEncoding)
+	if w.PreSharedKey != "" {
+		d.CheckBase64Key("presharedkey", w.PreSharedKey, base64.StdEncoding)
+	}
+	d.CheckPrefixes("address", w.LocalAddress)
+	d.CheckReserved("reserved", w.Reserved)
+	if w.Mtu != 0 && (w.Mtu < 576 || w.Mtu > 65535) {
+		d.Errorf("mtu", "invalid mtu %d", w.Mtu)
+	}
+
+	return d
+}
+
+func (w *Wireguard) DetailsStr() string {
+	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %d\n%s: %s\n%s: %v\n%s: %s\n",
+		color.RedString("Protocol"), w.Name(),
+		color.RedString("Remark"), w.Remark,
+		color.RedString("Endpoint"), w.Endpoint,
+		color.RedString("MTU"), w.Mtu,
+		color.RedString("Local Addresses"), w.LocalAddress,
+		color.RedString("Public Key"), w.PublicKey,
+		color.RedString("Secret Key"), Redact(w.SecretKey),
+	)
+
+	return info
+}
+
+func (w *Wireguard) ConvertToGeneralConfig() (g GeneralConfig) {
+	g.Protocol = w.Name()
+	g.Address = w.Endpoint
+	if host, port, err := net.SplitHostPort(w.Endpoint); err == nil {
+		g.Address, g.Port = host, port
+	}
+	g.Remark = w.Remark
+	g.OrigLink = w.OrigLink
+
+	return g
+}
+
+func (w *Wireguard) ToLink() string {
+	query := BuildQuery(w, "secretkey")
+
+	link := fmt.Sprintf("%s://%s@%s", WireguardIdentifier, url.User(w.SecretKey).String(), w.Endpoint)
+	if len(query) != 0 {
+		link += "?" + query.Encode()
+	}
+	if w.Remark != "" {
+		link += "#" + url.PathEscape(w.Remark)
+	}
+
+	return link
+}
@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+)
+
+// RenameData is what a rename template can use, e.g. "{{.Country}} {{.Delay}}ms {{.Protocol}}-{{.Network}} #{{.Index}}"
+type RenameData struct {
+	Index    int    // Position of the config in the output, starting from 1
+	Remark   string // Original remark
+	Protocol string
+	Network  string // Transport (tcp, ws, grpc, ...), empty for the protocols without one
+	Security string // none, tls, reality
+	Address  string
+	Port     int
+
+	Status   string
+	Core     string  // Core that tested the config (xray, singbox)
+	Delay    int64   // ms
+	IP       string  // Real IP address, when the IP info was requested
+	Country  string  // Country code of the real IP address, when the IP info was requested
+	Download float32 // mbps
+	Upload   float32 // mbps
+}
+
+// Renamer rewrites the remarks of tested configs with a text/template
+type Renamer struct {
+	tmpl *template.Template
+}
+
+// NewRenamer parses a rename template, see RenameData for the fields
+func NewRenamer(text string) (*Renamer, error) {
+	tmpl, err := template.New("rename").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rename template: %v", err)
+	}
+	// Unknown fields only fail on execution, better before testing all the configs than after
+	if err = tmpl.Execute(io.Discard, RenameData{}); err != nil {
+		return nil, fmt.Errorf("invalid rename template: %v", err)
+	}
+	return &Renamer{tmpl: tmpl}, nil
+}
+
+// Rename executes the template for a result and replaces its ConfigLink with the renamed link
+func (rn *Renamer) Rename(r *Result, index int) error {
+	if r.Protocol == nil {
+		return fmt.Errorf("result of %s has no parsed config", r.ConfigLink)
+	}
+
+	record := r.Protocol.ConvertToGeneralConfig().Record()
+	data := RenameData{
+		Index:    index,
+		Remark:   record.Remark,
+		Protocol: record.Protocol,
+		Security: string(record.Security),
+		Address:  record.Address,
+		Port:     record.Port,
+		Status:   r.Status,
+		Core:     r.Core,
+		Delay:    r.Delay,
+		Download: r.DownloadSpeed,
+		Upload:   r.UploadSpeed,
+	}
+	if record.Transport != nil {
+		data.Network = record.Transport.Network
+	}
+	// "null" is the placeholder of the CSV report
+	if r.RealIPAddr != "null" {
+		data.IP = r.RealIPAddr
+	}
+	if r.IpAddrLoc != "null" {
+		data.Country = r.IpAddrLoc
+	}
+
+	var buf bytes.Buffer
+	if err := rn.tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	// The tested protocol went through a core, rename a fresh parse of its link instead
+	// (the link of an alias is the rewritten one, it doesn't need the registry of the alias)
+	fresh, err := protocol.CreateProtocol(r.Protocol.ConvertToGeneralConfig().OrigLink)
+	if err == nil {
+		err = fresh.Parse()
+	}
+	if err != nil {
+		return err
+	}
+	link, err := protocol.Rename(fresh, strings.TrimSpace(buf.String()))
+	if err != nil {
+		return err
+	}
+	r.ConfigLink = link
+	return nil
+}
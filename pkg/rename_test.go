@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/naser-989/xray-knife/v3/pkg/xray"
+)
+
+func TestRenamer_Rename(t *testing.T) {
+	renamer, err := NewRenamer("{{.Country}} {{.Delay}}ms {{.Protocol}}-{{.Network}} #{{.Index}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := protocol.CreateProtocol("vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=ws&security=tls&path=%2Fws#old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := Result{ConfigLink: p.ToLink(), Protocol: p, Status: "passed", Delay: 120, RealIPAddr: "1.2.3.4", IpAddrLoc: "DE"}
+	if err = renamer.Rename(&r, 3); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(r.ConfigLink, "#DE%20120ms%20vless-ws%20%233") {
+		t.Errorf("unexpected renamed link %s", r.ConfigLink)
+	}
+
+	// The renamed link is the tested config, not the one the core built
+	link := "vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?flow=xtls-rprx-vision&security=tls&sni=example.com&type=grpc&serviceName=%2Fsvc#old"
+	p, err = protocol.CreateProtocol(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	want, _, _ := strings.Cut(p.ToLink(), "#")
+	built, err := xray.Build(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = built.BuildOutboundDetourConfig(false); err != nil {
+		t.Fatal(err)
+	}
+	// What a core filling its defaults into the parsed config would leave
+	p.(*protocol.Vless).Flow, p.(*protocol.Vless).TlsFingerprint = "", "chrome"
+
+	r = Result{ConfigLink: link, Protocol: built, Status: "passed"}
+	if err = renamer.Rename(&r, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(r.ConfigLink, want+"#") {
+		t.Errorf("the rename changed the config\ngot:  %s\nwant: %s#...", r.ConfigLink, want)
+	}
+
+	if _, err = NewRenamer("{{.Unknown}}"); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
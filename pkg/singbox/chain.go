@@ -0,0 +1,56 @@
+package singbox
+
+import (
+	"fmt"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+// DetourProtocol is implemented by protocols whose outbound dials through a
+// second outbound, like Shadowsocks wrapped in ShadowTLS.
+type DetourProtocol interface {
+	// CraftDetourOutboundOptions returns the outbound to dial through,
+	// or nil when the config doesn't need one
+	CraftDetourOutboundOptions(allowInsecure bool) (*option.Outbound, error)
+}
+
+// CraftChainOptions crafts the outbound of p tagged with tag, followed by the
+// outbound it dials through (if any) tagged with tag + "-detour".
+func CraftChainOptions(p Protocol, tag string, allowInsecure bool) ([]option.Outbound, error) {
+	out, err := p.CraftOutboundOptions(allowInsecure)
+	if err != nil {
+		return nil, err
+	}
+	out.Tag = tag
+	outbounds := []option.Outbound{*out}
+
+	dp, ok := p.(DetourProtocol)
+	if !ok {
+		return outbounds, nil
+	}
+	detour, err := dp.CraftDetourOutboundOptions(allowInsecure)
+	if err != nil {
+		return nil, err
+	}
+	if detour == nil {
+		return outbounds, nil
+	}
+
+	detour.Tag = tag + "-detour"
+	if err = setDetour(&outbounds[0], detour.Tag); err != nil {
+		return nil, err
+	}
+
+	return append(outbounds, *detour), nil
+}
+
+func setDetour(out *option.Outbound, tag string) error {
+	switch out.Type {
+	case C.TypeShadowsocks:
+		out.ShadowsocksOptions.Detour = tag
+	default:
+		return fmt.Errorf("%s outbound can't be chained", out.Type)
+	}
+	return nil
+}
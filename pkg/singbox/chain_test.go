@@ -0,0 +1,52 @@
+package singbox
+
+import (
+	"testing"
+)
+
+func TestCraftChainOptions(t *testing.T) {
+	link := "ss://YWVzLTI1Ni1nY206c2VjcmV0@example.com:443/?plugin=shadow-tls%3Bhost%3Dcloud.tencent.com%3Bpassword%3Dpw#stls"
+
+	ss := NewShadowsocks(link)
+	if err := ss.Parse(); err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+
+	outbounds, err := CraftChainOptions(ss, "proxy", false)
+	if err != nil {
+		t.Fatalf("Error when crafting outbounds: %v", err)
+	}
+	if len(outbounds) != 2 {
+		t.Fatalf("expected shadowsocks and shadowtls outbounds, got %d", len(outbounds))
+	}
+
+	if outbounds[0].Tag != "proxy" || outbounds[0].ShadowsocksOptions.Detour != "proxy-detour" {
+		t.Errorf("unexpected shadowsocks outbound: %+v", outbounds[0])
+	}
+	stls := outbounds[1].ShadowTLSOptions
+	if outbounds[1].Tag != "proxy-detour" || stls.Version != 3 || stls.Password != "pw" || stls.TLS.ServerName != "cloud.tencent.com" || stls.Server != "example.com" {
+		t.Errorf("unexpected shadowtls outbound: %+v", outbounds[1])
+	}
+
+	// The box has to accept the chain as it is
+	if _, err = NewSingboxService(false, false).MakeInstance(ss); err != nil {
+		t.Errorf("Error when making instance: %v", err)
+	}
+
+	// Without a plugin there is nothing to chain
+	plain := NewShadowsocks("ss://YWVzLTI1Ni1nY206RXhhbXBsZUAxMjM0@example.com:443#exa")
+	if err = plain.Parse(); err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+	if outbounds, err = CraftChainOptions(plain, "proxy", false); err != nil || len(outbounds) != 1 {
+		t.Errorf("unexpected plain shadowsocks chain: %+v, %v", outbounds, err)
+	}
+
+	missingHost := NewShadowsocks("ss://YWVzLTI1Ni1nY206c2VjcmV0@example.com:443/?plugin=shadow-tls%3Bpassword%3Dpw")
+	if err = missingHost.Parse(); err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+	if _, err = CraftChainOptions(missingHost, "proxy", false); err == nil {
+		t.Errorf("expected an error for a shadow-tls plugin without host")
+	}
+}
@@ -0,0 +1,564 @@
+package singbox
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/naser-989/xray-knife/v3/utils"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json"
+)
+
+// LoadOutbounds reads a full sing-box JSON config and turns every entry of its
+// "outbounds" array into the matching protocol struct.
+// Outbounds that can't be mapped (direct, block, dns, selector, ...) are skipped and
+// reported in the returned error slice.
+func LoadOutbounds(data []byte) ([]Protocol, []error) {
+	// Only the outbounds matter here, decoding the whole option.Options would
+	// also reject configs written for other sing-box versions
+	config, err := json.UnmarshalExtended[struct {
+		Outbounds []option.Outbound `json:"outbounds"`
+	}](data)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	// ShadowTLS outbounds are folded into the shadowsocks outbounds dialing through them
+	shadowTLS := make(map[string]*option.Outbound)
+	for i := range config.Outbounds {
+		if out := &config.Outbounds[i]; out.Type == "shadowtls" && out.Tag != "" {
+			shadowTLS[out.Tag] = out
+		}
+	}
+	detours := make(map[string]bool)
+	for _, out := range config.Outbounds {
+		if out.Type == "shadowsocks" && shadowTLS[out.ShadowsocksOptions.Detour] != nil {
+			detours[out.ShadowsocksOptions.Detour] = true
+		}
+	}
+
+	var protocols []Protocol
+	var errs []error
+	for i := range config.Outbounds {
+		out := &config.Outbounds[i]
+		if out.Type == "shadowtls" && detours[out.Tag] {
+			continue
+		}
+
+		var p Protocol
+		var err error
+		if detour := shadowTLS[out.ShadowsocksOptions.Detour]; out.Type == "shadowsocks" && detour != nil {
+			var s protocol.Protocol
+			if s, err = shadowsocksFromShadowTLS(out, detour); err == nil {
+				setOrigLink(s)
+				p, err = Build(s)
+			}
+		} else {
+			p, err = NewFromOutbound(out)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("outbound #%d (%s, tag %q): %v", i+1, out.Type, out.Tag, err))
+			continue
+		}
+		protocols = append(protocols, p)
+	}
+
+	return protocols, errs
+}
+
+// NewFromOutbound converts a single sing-box outbound into its protocol struct.
+// The OrigLink of the result is set to its share link.
+func NewFromOutbound(out *option.Outbound) (Protocol, error) {
+	var p protocol.Protocol
+	var err error
+	switch out.Type {
+	case "vmess":
+		p, err = vmessFromOutbound(out)
+	case "vless":
+		p, err = vlessFromOutbound(out)
+	case "trojan":
+		p, err = trojanFromOutbound(out)
+	case "shadowsocks":
+		p, err = shadowsocksFromOutbound(out)
+	case "socks":
+		p, err = socksFromOutbound(out)
+	case "http":
+		p, err = httpFromOutbound(out)
+	case "wireguard":
+		p, err = wireguardFromOutbound(out)
+	case "hysteria2":
+		p, err = hysteria2FromOutbound(out)
+	case "tuic":
+		p, err = tuicFromOutbound(out)
+	case "hysteria":
+		p, err = hysteriaFromOutbound(out)
+	default:
+		return nil, fmt.Errorf("unsupported outbound type %q", out.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	setOrigLink(p)
+	return Build(p)
+}
+
+// setOrigLink fills the OrigLink of a protocol built from a JSON config
+func setOrigLink(p protocol.Protocol) {
+	link := p.ToLink()
+	switch v := p.(type) {
+	case *protocol.Vmess:
+		v.OrigLink = link
+	case *protocol.Vless:
+		v.OrigLink = link
+	case *protocol.Trojan:
+		v.OrigLink = link
+	case *protocol.Shadowsocks:
+		v.OrigLink = link
+	case *protocol.Socks:
+		v.OrigLink = link
+	case *protocol.Http:
+		v.OrigLink = link
+	case *protocol.Wireguard:
+		v.OrigLink = link
+	case *protocol.Hysteria2:
+		v.OrigLink = link
+	case *protocol.Tuic:
+		v.OrigLink = link
+	case *protocol.Hysteria:
+		v.OrigLink = link
+	}
+}
+
+// serverAddress returns the server and port of an outbound the way Parse stores them
+func serverAddress(s option.ServerOptions) (string, string, error) {
+	if s.Server == "" {
+		return "", "", errors.New("outbound has no server")
+	}
+
+	address := s.Server
+	if utils.IsIPv6(address) {
+		address = "[" + address + "]"
+	}
+	return address, strconv.Itoa(int(s.ServerPort)), nil
+}
+
+// tlsFields is the TLS part of an outbound flattened into the share link parameters
+type tlsFields struct {
+	Security    string
+	SNI         string
+	ALPN        string
+	Fingerprint string
+	Insecure    string
+	PublicKey   string
+	ShortID     string
+}
+
+func flattenTLS(t *option.OutboundTLSOptions) (f tlsFields) {
+	if t == nil || !t.Enabled {
+		return f
+	}
+
+	f.Security = "tls"
+	f.SNI = t.ServerName
+	f.ALPN = strings.Join(t.ALPN, ",")
+	if t.Insecure {
+		f.Insecure = "1"
+	}
+	if t.UTLS != nil && t.UTLS.Enabled {
+		f.Fingerprint = t.UTLS.Fingerprint
+	}
+	if t.Reality != nil && t.Reality.Enabled {
+		f.Security = "reality"
+		f.PublicKey = t.Reality.PublicKey
+		f.ShortID = t.Reality.ShortID
+	}
+
+	return f
+}
+
+// transportFields is the V2Ray transport of an outbound flattened into the share link parameters
+type transportFields struct {
+	Network     string
+	Host        string
+	Path        string
+	ServiceName string
+}
+
+func flattenTransport(t *option.V2RayTransportOptions) (f transportFields, err error) {
+	f.Network = "tcp"
+	if t == nil || t.Type == "" {
+		return f, nil
+	}
+
+	f.Network = t.Type
+	switch t.Type {
+	case "ws":
+		f.Path = t.WebsocketOptions.Path
+		for name, values := range t.WebsocketOptions.Headers {
+			if strings.EqualFold(name, "host") && len(values) != 0 {
+				f.Host = values[0]
+			}
+		}
+	case "http":
+		f.Host = strings.Join(t.HTTPOptions.Host, ",")
+		f.Path = t.HTTPOptions.Path
+	case "httpupgrade":
+		f.Host = t.HTTPUpgradeOptions.Host
+		f.Path = t.HTTPUpgradeOptions.Path
+	case "grpc":
+		f.ServiceName = t.GRPCOptions.ServiceName
+	case "quic":
+	default:
+		return f, fmt.Errorf("unsupported transport %q", t.Type)
+	}
+
+	return f, nil
+}
+
+func vmessFromOutbound(out *option.Outbound) (protocol.Protocol, error) {
+	opts := out.VMessOptions
+	address, port, err := serverAddress(opts.ServerOptions)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := flattenTransport(opts.Transport)
+	if err != nil {
+		return nil, err
+	}
+	tls := flattenTLS(opts.TLS)
+
+	v := &protocol.Vmess{
+		Version:        "2",
+		Address:        address,
+		Aid:            strconv.Itoa(opts.AlterId),
+		Port:           port,
+		Security:       opts.Security,
+		Host:           tr.Host,
+		ID:             opts.UUID,
+		Network:        tr.Network,
+		Path:           tr.Path,
+		Remark:         out.Tag,
+		TLS:            tls.Security,
+		AllowInsecure:  tls.Insecure,
+		SNI:            tls.SNI,
+		ALPN:           tls.ALPN,
+		TlsFingerprint: tls.Fingerprint,
+	}
+	if v.Security == "" {
+		v.Security = "auto"
+	}
+	if tr.Network == "grpc" {
+		v.Path = tr.ServiceName
+	}
+
+	return v, nil
+}
+
+func vlessFromOutbound(out *option.Outbound) (protocol.Protocol, error) {
+	opts := out.VLESSOptions
+	address, port, err := serverAddress(opts.ServerOptions)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := flattenTransport(opts.Transport)
+	if err != nil {
+		return nil, err
+	}
+	tls := flattenTLS(opts.TLS)
+
+	return &protocol.Vless{
+		ID:             opts.UUID,
+		Address:        address,
+		Encryption:     "none",
+		Flow:           opts.Flow,
+		Security:       tls.Security,
+		PublicKey:      tls.PublicKey,
+		ShortIds:       tls.ShortID,
+		Host:           tr.Host,
+		Path:           tr.Path,
+		Port:           port,
+		SNI:            tls.SNI,
+		ALPN:           tls.ALPN,
+		TlsFingerprint: tls.Fingerprint,
+		AllowInsecure:  tls.Insecure,
+		Type:           tr.Network,
+		Remark:         out.Tag,
+		ServiceName:    tr.ServiceName,
+	}, nil
+}
+
+func trojanFromOutbound(out *option.Outbound) (protocol.Protocol, error) {
+	opts := out.TrojanOptions
+	address, port, err := serverAddress(opts.ServerOptions)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := flattenTransport(opts.Transport)
+	if err != nil {
+		return nil, err
+	}
+	tls := flattenTLS(opts.TLS)
+
+	return &protocol.Trojan{
+		Password:       opts.Password,
+		Address:        address,
+		Security:       tls.Security,
+		Host:           tr.Host,
+		Path:           tr.Path,
+		Port:           port,
+		SNI:            tls.SNI,
+		ALPN:           tls.ALPN,
+		TlsFingerprint: tls.Fingerprint,
+		AllowInsecure:  tls.Insecure,
+		Type:           tr.Network,
+		Remark:         out.Tag,
+		ServiceName:    tr.ServiceName,
+		PublicKey:      tls.PublicKey,
+		ShortIds:       tls.ShortID,
+	}, nil
+}
+
+func shadowsocksFromOutbound(out *option.Outbound) (protocol.Protocol, error) {
+	opts := out.ShadowsocksOptions
+	address, port, err := serverAddress(opts.ServerOptions)
+	if err != nil {
+		return nil, err
+	}
+	switch opts.Plugin {
+	case "", "obfs-local", "v2ray-plugin":
+	default:
+		return nil, fmt.Errorf("shadowsocks plugin %q is not supported", opts.Plugin)
+	}
+
+	return &protocol.Shadowsocks{
+		Address:    address,
+		Port:       port,
+		Encryption: opts.Method,
+		Password:   opts.Password,
+		Remark:     out.Tag,
+		Plugin:     opts.Plugin,
+		PluginOpts: opts.PluginOptions,
+	}, nil
+}
+
+// shadowsocksFromShadowTLS maps a shadowsocks outbound and the ShadowTLS outbound it dials
+// through into a single Shadowsocks with the shadow-tls SIP002 plugin
+func shadowsocksFromShadowTLS(out, detour *option.Outbound) (protocol.Protocol, error) {
+	opts := out.ShadowsocksOptions
+	if opts.Plugin != "" {
+		return nil, fmt.Errorf("shadowsocks plugin %q is not supported", opts.Plugin)
+	}
+
+	tlsOpts := detour.ShadowTLSOptions
+	// The shadowsocks server is only reached through ShadowTLS
+	address, port, err := serverAddress(tlsOpts.ServerOptions)
+	if err != nil {
+		return nil, err
+	}
+	tls := flattenTLS(tlsOpts.TLS)
+	if tls.SNI == "" {
+		return nil, errors.New("shadowtls outbound has no server_name")
+	}
+
+	version := tlsOpts.Version
+	if version == 0 {
+		version = 1
+	}
+	pluginOpts := []string{"host=" + tls.SNI}
+	if tlsOpts.Password != "" {
+		pluginOpts = append(pluginOpts, "password="+tlsOpts.Password)
+	}
+	pluginOpts = append(pluginOpts, "version="+strconv.Itoa(version))
+	if tls.Fingerprint != "" {
+		pluginOpts = append(pluginOpts, "fp="+tls.Fingerprint)
+	}
+
+	return &protocol.Shadowsocks{
+		Address:    address,
+		Port:       port,
+		Encryption: opts.Method,
+		Password:   opts.Password,
+		Remark:     out.Tag,
+		Plugin:     "shadow-tls",
+		PluginOpts: strings.Join(pluginOpts, ";"),
+	}, nil
+}
+
+func socksFromOutbound(out *option.Outbound) (protocol.Protocol, error) {
+	opts := out.SocksOptions
+	address, port, err := serverAddress(opts.ServerOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protocol.Socks{
+		Remark:   out.Tag,
+		Address:  address,
+		Port:     port,
+		Username: opts.Username,
+		Password: opts.Password,
+	}, nil
+}
+
+func httpFromOutbound(out *option.Outbound) (protocol.Protocol, error) {
+	opts := out.HTTPOptions
+	if opts.Server == "" {
+		return nil, errors.New("outbound has no server")
+	}
+	if opts.Path != "" || len(opts.Headers) != 0 {
+		return nil, errors.New("http path and headers are not supported")
+	}
+	tls := flattenTLS(opts.TLS)
+	if tls.Security == "reality" {
+		return nil, errors.New("http over reality is not supported")
+	}
+
+	// Http.Parse keeps IPv6 addresses unbracketed
+	return &protocol.Http{
+		Remark:         out.Tag,
+		Address:        opts.Server,
+		Port:           strconv.Itoa(int(opts.ServerPort)),
+		Username:       opts.Username,
+		Password:       opts.Password,
+		TLS:            tls.Security,
+		SNI:            tls.SNI,
+		TlsFingerprint: tls.Fingerprint,
+		AllowInsecure:  tls.Insecure,
+	}, nil
+}
+
+func wireguardFromOutbound(out *option.Outbound) (protocol.Protocol, error) {
+	opts := out.WireGuardOptions
+
+	// Multi-peer configs are reduced to their first peer
+	server := opts.ServerOptions
+	publicKey := opts.PeerPublicKey
+	preSharedKey := opts.PreSharedKey
+	reserved := opts.Reserved
+	if len(opts.Peers) != 0 {
+		server = opts.Peers[0].ServerOptions
+		publicKey = opts.Peers[0].PublicKey
+		preSharedKey = opts.Peers[0].PreSharedKey
+		reserved = opts.Peers[0].Reserved
+	}
+	if server.Server == "" {
+		return nil, errors.New("wireguard outbound has no peer")
+	}
+
+	var localAddresses []string
+	for _, prefix := range opts.LocalAddress {
+		localAddresses = append(localAddresses, prefix.String())
+	}
+
+	var reservedValues []string
+	for _, v := range reserved {
+		reservedValues = append(reservedValues, strconv.Itoa(int(v)))
+	}
+
+	return &protocol.Wireguard{
+		Remark:       out.Tag,
+		PublicKey:    publicKey,
+		SecretKey:    opts.PrivateKey,
+		PreSharedKey: preSharedKey,
+		Endpoint:     protocol.JoinHostPort(server.Server, strconv.Itoa(int(server.ServerPort))),
+		Reserved:     strings.Join(reservedValues, ","),
+		LocalAddress: strings.Join(localAddresses, ","),
+		Mtu:          int32(opts.MTU),
+	}, nil
+}
+
+func hysteria2FromOutbound(out *option.Outbound) (protocol.Protocol, error) {
+	opts := out.Hysteria2Options
+	if opts.Server == "" {
+		return nil, errors.New("outbound has no server")
+	}
+	tls := flattenTLS(opts.TLS)
+
+	// Hysteria2.Parse keeps IPv6 addresses unbracketed
+	h := &protocol.Hysteria2{
+		Remark:   out.Tag,
+		Address:  opts.Server,
+		Port:     strconv.Itoa(int(opts.ServerPort)),
+		Password: opts.Password,
+		SNI:      tls.SNI,
+		Insecure: tls.Insecure,
+		ALPN:     tls.ALPN,
+	}
+	if opts.Obfs != nil {
+		h.ObfusType = opts.Obfs.Type
+		h.ObfusPassword = opts.Obfs.Password
+	}
+	if opts.UpMbps != 0 {
+		h.Up = fmt.Sprintf("%d Mbps", opts.UpMbps)
+	}
+	if opts.DownMbps != 0 {
+		h.Down = fmt.Sprintf("%d Mbps", opts.DownMbps)
+	}
+
+	return h, nil
+}
+
+func tuicFromOutbound(out *option.Outbound) (protocol.Protocol, error) {
+	opts := out.TUICOptions
+	if opts.Server == "" {
+		return nil, errors.New("outbound has no server")
+	}
+	tls := flattenTLS(opts.TLS)
+
+	t := &protocol.Tuic{
+		Remark:            out.Tag,
+		Address:           opts.Server,
+		Port:              strconv.Itoa(int(opts.ServerPort)),
+		UUID:              opts.UUID,
+		Password:          opts.Password,
+		CongestionControl: opts.CongestionControl,
+		UDPRelayMode:      opts.UDPRelayMode,
+		ALPN:              tls.ALPN,
+		SNI:               tls.SNI,
+		Insecure:          tls.Insecure,
+	}
+	if opts.TLS != nil && opts.TLS.DisableSNI {
+		t.DisableSNI = "1"
+	}
+
+	return t, nil
+}
+
+func hysteriaFromOutbound(out *option.Outbound) (protocol.Protocol, error) {
+	opts := out.HysteriaOptions
+	if opts.Server == "" {
+		return nil, errors.New("outbound has no server")
+	}
+	if len(opts.Auth) != 0 {
+		return nil, errors.New("binary auth is not supported, use auth_str")
+	}
+	tls := flattenTLS(opts.TLS)
+
+	h := &protocol.Hysteria{
+		Remark:    out.Tag,
+		Address:   opts.Server,
+		Port:      strconv.Itoa(int(opts.ServerPort)),
+		Auth:      opts.AuthString,
+		SNI:       tls.SNI,
+		Insecure:  tls.Insecure,
+		UpMbps:    opts.Up,
+		DownMbps:  opts.Down,
+		ALPN:      tls.ALPN,
+		ObfsParam: opts.Obfs,
+	}
+	if opts.UpMbps != 0 {
+		h.UpMbps = strconv.Itoa(opts.UpMbps)
+	}
+	if opts.DownMbps != 0 {
+		h.DownMbps = strconv.Itoa(opts.DownMbps)
+	}
+	if h.ObfsParam != "" {
+		h.Obfs = "xplus"
+	}
+
+	return h, nil
+}
@@ -0,0 +1,101 @@
+package singbox
+
+import "testing"
+
+func TestLoadOutbounds(t *testing.T) {
+	config := `{
+  // Comments are allowed in sing-box configs
+  "outbounds": [
+    {
+      "type": "vless",
+      "tag": "vless-ws",
+      "server": "example.com",
+      "server_port": 443,
+      "uuid": "0090bbba-1118-46ca-87a1-52599cee74ab",
+      "tls": { "enabled": true, "server_name": "example.com", "utls": { "enabled": true, "fingerprint": "chrome" } },
+      "transport": { "type": "ws", "path": "/ws", "headers": { "Host": "cdn.example.com" } }
+    },
+    {
+      "type": "hysteria2",
+      "tag": "hy2",
+      "server": "1.2.3.4",
+      "server_port": 8443,
+      "password": "secret",
+      "obfs": { "type": "salamander", "password": "obfs" },
+      "tls": { "enabled": true, "server_name": "example.com", "insecure": true }
+    },
+    {
+      "type": "wireguard",
+      "tag": "wg",
+      "server": "162.159.192.1",
+      "server_port": 2408,
+      "local_address": ["172.16.0.2/32"],
+      "private_key": "eCtXsJZ27+4PbhDkHnB923tkUn2Gj59wZw5wFA75MnU=",
+      "peer_public_key": "bmXOC+F1FxEMF9dyiK2H5/1SUtzH0JuVo51h2wPfgyo=",
+      "reserved": [1, 2, 3],
+      "mtu": 1280
+    },
+    {
+      "type": "shadowsocks",
+      "tag": "ss-stls",
+      "method": "2022-blake3-aes-128-gcm",
+      "password": "secret",
+      "detour": "stls"
+    },
+    {
+      "type": "shadowtls",
+      "tag": "stls",
+      "server": "5.6.7.8",
+      "server_port": 443,
+      "version": 3,
+      "password": "pw",
+      "tls": { "enabled": true, "server_name": "cloud.tencent.com" }
+    },
+    { "type": "direct", "tag": "direct" },
+    { "type": "selector", "tag": "select", "outbounds": ["vless-ws", "hy2"] }
+  ]
+}`
+
+	protocols, errs := LoadOutbounds([]byte(config))
+	if len(protocols) != 4 {
+		t.Fatalf("expected 4 outbounds, got %d (errors: %v)", len(protocols), errs)
+	}
+	if len(errs) != 2 {
+		t.Errorf("expected the direct and selector outbounds to be reported, got %v", errs)
+	}
+
+	vless := protocols[0].(*Vless)
+	if vless.Type != "ws" || vless.Host != "cdn.example.com" || vless.Path != "/ws" || vless.Security != "tls" || vless.TlsFingerprint != "chrome" {
+		t.Errorf("unexpected vless outbound: %+v", vless)
+	}
+
+	hy2 := protocols[1].(*Hysteria2)
+	if hy2.Password != "secret" || hy2.ObfusType != "salamander" || hy2.Insecure != "1" || hy2.Remark != "hy2" {
+		t.Errorf("unexpected hysteria2 outbound: %+v", hy2)
+	}
+
+	wg := protocols[2].(*Wireguard)
+	if wg.Endpoint != "162.159.192.1:2408" || wg.Reserved != "1,2,3" || wg.LocalAddress != "172.16.0.2/32" || wg.Mtu != 1280 {
+		t.Errorf("unexpected wireguard outbound: %+v", wg)
+	}
+
+	ss := protocols[3].(*Shadowsocks)
+	if ss.Address != "5.6.7.8" || ss.Port != "443" || ss.Plugin != "shadow-tls" || ss.PluginOpts != "host=cloud.tencent.com;password=pw;version=3" {
+		t.Errorf("unexpected shadowsocks outbound: %+v", ss)
+	}
+
+	// The generated links must be parsable like any other link
+	for _, p := range protocols {
+		link := p.ToLink()
+		parsed, err := NewSingboxService(false, false).CreateProtocol(link)
+		if err != nil {
+			t.Fatalf("%s: %v", link, err)
+		}
+		if err = parsed.Parse(); err != nil {
+			t.Errorf("%s: %v", link, err)
+		}
+		if _, err = parsed.(Protocol).CraftOutboundOptions(false); err != nil {
+			t.Errorf("%s: %v", link, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package singbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/outbound"
+	"github.com/sagernet/sing/common/logger"
+	"strconv"
+)
+
+func NewHttp(link string) Protocol {
+	return &Http{protocol.NewHttp(link)}
+}
+
+func (h *Http) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, error) {
+	port, err := strconv.Atoi(h.Port)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", h.Port)
+	}
+
+	opts := option.HTTPOutboundOptions{
+		DialerOptions: option.DialerOptions{},
+		ServerOptions: option.ServerOptions{
+			Server:     h.Address,
+			ServerPort: uint16(port),
+		},
+		Username: h.Username,
+		Password: h.Password,
+	}
+
+	if h.TLS == "tls" {
+		var insecure = allowInsecure
+		if h.AllowInsecure == "1" || h.AllowInsecure == "true" {
+			insecure = true
+		}
+
+		opts.TLS = &option.OutboundTLSOptions{
+			Enabled:    true,
+			ServerName: h.SNI,
+			Insecure:   insecure,
+		}
+		if h.TlsFingerprint != "" && h.TlsFingerprint != "none" {
+			opts.TLS.UTLS = &option.OutboundUTLSOptions{
+				Enabled:     true,
+				Fingerprint: h.TlsFingerprint,
+			}
+		}
+	}
+
+	return &option.Outbound{
+		Type:        h.Name(),
+		HTTPOptions: opts,
+	}, nil
+}
+
+func (h *Http) CraftInboundOptions() *option.Inbound {
+	return &option.Inbound{
+		Type: h.Name(),
+	}
+}
+
+func (h *Http) CraftOutbound(ctx context.Context, l logger.ContextLogger, allowInsecure bool) (adapter.Outbound, error) {
+	options, err := h.CraftOutboundOptions(allowInsecure)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := outbound.New(ctx, adapter.RouterFromContext(ctx), l, "out_http", *options)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating http outbound: %v", err)
+	}
+
+	return out, nil
+}
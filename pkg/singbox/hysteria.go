@@ -0,0 +1,110 @@
+package singbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/outbound"
+	"github.com/sagernet/sing/common/logger"
+	"strconv"
+	"strings"
+)
+
+// Hysteria v1 needs the client bandwidth, these are used when the link doesn't have it
+const (
+	defaultHysteriaUpMbps   = 10
+	defaultHysteriaDownMbps = 50
+)
+
+func NewHysteria(link string) Protocol {
+	return &Hysteria{protocol.NewHysteria(link)}
+}
+
+// bandwidth fills either the Mbps number or the speed string (e.g. "100 Mbps") of sing-box
+func bandwidth(value string, defaultMbps int) (int, string) {
+	if value == "" {
+		return defaultMbps, ""
+	}
+	if mbps, err := strconv.Atoi(value); err == nil {
+		return mbps, ""
+	}
+	return 0, value
+}
+
+func (h *Hysteria) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, error) {
+	switch h.Protocol {
+	case "", "udp":
+	default:
+		return nil, fmt.Errorf("hysteria %s protocol is not supported by sing-box", h.Protocol)
+	}
+
+	port, _ := strconv.Atoi(h.Port)
+	var insecure = allowInsecure
+
+	if h.Insecure == "1" || h.Insecure == "true" {
+		insecure = true
+	}
+
+	alpn := []string{"hysteria"}
+	if h.ALPN != "" && h.ALPN != "none" {
+		alpn = strings.Split(h.ALPN, ",")
+	}
+
+	// The official links carry the xplus password in obfsParam,
+	// others put the password straight into obfs
+	obfs := h.ObfsParam
+	if obfs == "" && h.Obfs != "xplus" {
+		obfs = h.Obfs
+	}
+	if h.Obfs == "xplus" && obfs == "" {
+		return nil, errors.New("hysteria xplus obfuscation without password")
+	}
+
+	opts := option.HysteriaOutboundOptions{
+		DialerOptions: option.DialerOptions{},
+		ServerOptions: option.ServerOptions{
+			Server:     h.Address,
+			ServerPort: uint16(port),
+		},
+		AuthString: h.Auth,
+		Obfs:       obfs,
+		OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+			TLS: &option.OutboundTLSOptions{
+				Enabled:    true,
+				ServerName: h.SNI,
+				Insecure:   insecure,
+				ALPN:       alpn,
+			},
+		},
+	}
+	opts.UpMbps, opts.Up = bandwidth(h.UpMbps, defaultHysteriaUpMbps)
+	opts.DownMbps, opts.Down = bandwidth(h.DownMbps, defaultHysteriaDownMbps)
+
+	return &option.Outbound{
+		Type:            h.Name(),
+		HysteriaOptions: opts,
+	}, nil
+}
+
+func (h *Hysteria) CraftInboundOptions() *option.Inbound {
+	return &option.Inbound{
+		Type: h.Name(),
+	}
+}
+
+func (h *Hysteria) CraftOutbound(ctx context.Context, l logger.ContextLogger, allowInsecure bool) (adapter.Outbound, error) {
+	options, err := h.CraftOutboundOptions(allowInsecure)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := outbound.New(ctx, adapter.RouterFromContext(ctx), l, "out_hysteria", *options)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating hysteria outbound: %v", err)
+	}
+
+	return out, nil
+}
@@ -3,114 +3,28 @@ package singbox
 import (
 	"context"
 	"fmt"
-	"github.com/fatih/color"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-box/outbound"
 	"github.com/sagernet/sing/common/logger"
-	"net"
-	"net/url"
-	"reflect"
 	"strconv"
+	"strings"
 )
 
 func NewHysteria2(link string) Protocol {
-	return &Hysteria2{OrigLink: link}
+	return &Hysteria2{protocol.NewHysteria2(link)}
 }
 
-func (h *Hysteria2) Name() string {
-	return protocol.Hysteria2Identifier
-}
-
-func (h *Hysteria2) Parse() error {
-	uri, err := url.Parse(h.OrigLink)
-	if err != nil {
-		return err
-	}
-
-	h.Password = uri.User.String()
-
-	h.Address, h.Port, err = net.SplitHostPort(uri.Host)
-	if err != nil {
-		return err
-	}
-
-	//if utils.IsIPv6(h.Address) {
-	//	v.Address = "[" + v.Address + "]"
-	//}
-
-	// Get the type of the struct
-	t := reflect.TypeOf(*h)
-
-	// Get the number of fields in the struct
-	numFields := t.NumField()
-
-	// Iterate over each field of the struct
-	for i := 0; i < numFields; i++ {
-		field := t.Field(i)
-		tag := field.Tag.Get("json")
-
-		// If the query value exists for the field, set it
-		if values, ok := uri.Query()[tag]; ok {
-
-			value := values[0]
-			v := reflect.ValueOf(h).Elem().FieldByName(field.Name)
-
-			switch v.Type().String() {
-			case "string":
-				v.SetString(value)
-			case "int":
-				var intValue int
-				fmt.Sscanf(value, "%d", &intValue)
-				v.SetInt(int64(intValue))
-			}
-		}
-	}
-
-	h.Remark, err = url.PathUnescape(uri.Fragment)
-	if err != nil {
-		h.Remark = uri.Fragment
-	}
-
-	return nil
-}
-
-func (h *Hysteria2) DetailsStr() string {
-	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %v\n%s: %s\n",
-		color.RedString("Protocol"), h.Name(),
-		color.RedString("Remark"), h.Remark,
-		color.RedString("Address"), h.Address,
-		color.RedString("Port"), h.Port,
-		color.RedString("Password"), h.Password,
-		color.RedString("SNI"), h.SNI)
-
-	if h.Insecure != "" {
-		info += fmt.Sprintf("%s: %v\n",
-			color.RedString("Insecure"), h.Insecure)
-	}
-
-	if h.ObfusType != "" {
-		info += fmt.Sprintf("%s: %s\n%s: %s\n",
-			color.RedString("Obfuscation Type"), h.ObfusType,
-			color.RedString("Obfuscation Password"), h.ObfusPassword)
+func (h *Hysteria2) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, error) {
+	// sing-box can't hop between ports on its own, the same port out of the hopping range is always used
+	port, _ := strconv.Atoi(h.HopPort())
+	// There is no certificate pinning in this sing-box version, testing without the pin
+	// would report a config that the server relies on it for as passing
+	if h.PinSHA256 != "" {
+		return nil, fmt.Errorf("pinSHA256 certificate pinning is not supported by sing-box core")
 	}
-	return info
-}
 
-func (h *Hysteria2) ConvertToGeneralConfig() (g protocol.GeneralConfig) {
-	g.Protocol = h.Name()
-	g.Address = h.Address
-	g.Port = h.Port
-	g.Remark = h.Remark
-
-	g.OrigLink = h.OrigLink
-
-	return g
-}
-
-func (h *Hysteria2) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, error) {
-	port, _ := strconv.Atoi(h.Port)
 	var insecure = allowInsecure
 
 	if h.Insecure != "" {
@@ -134,6 +48,20 @@ func (h *Hysteria2) CraftOutboundOptions(allowInsecure bool) (*option.Outbound,
 			},
 		},
 	}
+	if h.ALPN != "" && h.ALPN != "none" {
+		opts.TLS.ALPN = strings.Split(h.ALPN, ",")
+	}
+	var err error
+	if h.Up != "" {
+		if opts.UpMbps, err = protocol.ParseMbps(h.Up); err != nil {
+			return nil, err
+		}
+	}
+	if h.Down != "" {
+		if opts.DownMbps, err = protocol.ParseMbps(h.Down); err != nil {
+			return nil, err
+		}
+	}
 
 	if h.ObfusType != "" {
 		opts.Obfs = &option.Hysteria2Obfs{
@@ -17,6 +17,52 @@ func TestNewHysteria2(t *testing.T) {
 	t.Logf("%s\n", hys2.DetailsStr())
 }
 
+func TestHysteria2_PortHopping(t *testing.T) {
+	link := "hysteria2://pw@example.com:443,20000-30000/?sni=example.com&up=100%20Mbps&down=1%20Gbps&alpn=h3&pinSHA256=deadbeef#hop"
+
+	hys2 := NewHysteria2(link).(*Hysteria2)
+	if err := hys2.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if hys2.Port != "443" || hys2.MPort != "443,20000-30000" {
+		t.Errorf("unexpected ports %q and %q", hys2.Port, hys2.MPort)
+	}
+	if hys2.PinSHA256 != "deadbeef" {
+		t.Errorf("unexpected pinSHA256 %q", hys2.PinSHA256)
+	}
+
+	if _, err := hys2.CraftOutboundOptions(false); err == nil {
+		t.Error("expected an error for the certificate pinning sing-box can't do")
+	}
+	hys2.PinSHA256 = ""
+
+	out, err := hys2.CraftOutboundOptions(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := out.Hysteria2Options
+	if opts.ServerPort != 443 {
+		t.Errorf("got port %d, want the first one of the hopping range", opts.ServerPort)
+	}
+	if opts.UpMbps != 100 || opts.DownMbps != 1000 {
+		t.Errorf("unexpected bandwidth %d/%d", opts.UpMbps, opts.DownMbps)
+	}
+	if len(opts.TLS.ALPN) != 1 || opts.TLS.ALPN[0] != "h3" {
+		t.Errorf("unexpected alpn %v", opts.TLS.ALPN)
+	}
+}
+
+func TestHysteria2_BadHopPorts(t *testing.T) {
+	for _, link := range []string{
+		"hysteria2://pw@example.com:443,1-/?sni=example.com#bad",
+		"hysteria2://pw@example.com:443/?mport=30000-20000#bad",
+	} {
+		if err := NewHysteria2(link).Parse(); err == nil {
+			t.Errorf("%s: expected a parse error", link)
+		}
+	}
+}
+
 //func TestHysteria2_MakeHttpClient(t *testing.T) {
 //	var hys2 Hysteria2
 //	err := hys2.Parse("hysteria2://fKt0mUHH2UKx6kl3xdI43yiV@laser.kafsabtaheri.com:8443/?sni=laser.kafsabtaheri.com&alpn=h3%2Ch2%2Chttp%2F1.1&obfs=salamander&obfs-password=HGdgfYUJFGjgD&insecure=1#H")
@@ -0,0 +1,42 @@
+package singbox
+
+import (
+	"testing"
+)
+
+func TestNewHysteria(t *testing.T) {
+	link := "hysteria://example.com:443?protocol=udp&auth_str=secret&sni=example.com&up_mbps=30&down_mbps=100&obfs=xplus&obfsParam=obfs#hysteria"
+
+	hys := NewHysteria(link)
+	err := hys.Parse()
+	if err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+
+	h := hys.(*Hysteria)
+	if h.Auth != "secret" || h.SNI != "example.com" || h.UpMbps != "30" || h.DownMbps != "100" {
+		t.Errorf("aliases weren't parsed: %+v", h)
+	}
+
+	out, err := hys.CraftOutboundOptions(false)
+	if err != nil {
+		t.Fatalf("Error when crafting outbound: %v", err)
+	}
+	opts := out.HysteriaOptions
+	if opts.AuthString != "secret" || opts.UpMbps != 30 || opts.DownMbps != 100 || opts.Obfs != "obfs" {
+		t.Errorf("unexpected outbound options: %+v", opts)
+	}
+
+	t.Logf("%s\n", hys.DetailsStr())
+}
+
+func TestHysteria_FakeTCP(t *testing.T) {
+	hys := NewHysteria("hysteria://example.com:443?protocol=faketcp&auth=secret#faketcp")
+	if err := hys.Parse(); err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+
+	if _, err := hys.CraftOutboundOptions(false); err == nil {
+		t.Error("faketcp should be reported as unsupported")
+	}
+}
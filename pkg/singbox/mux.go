@@ -0,0 +1,23 @@
+package singbox
+
+import (
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/sagernet/sing-box/option"
+)
+
+// multiplexOptions returns the multiplex options of an outbound, nil when the multiplexing is off
+func multiplexOptions(m protocol.Mux) *option.OutboundMultiplexOptions {
+	if !m.Enabled {
+		return nil
+	}
+	opts := &option.OutboundMultiplexOptions{
+		Enabled:  true,
+		Protocol: m.Protocol,
+		Padding:  m.Padding,
+	}
+	// -1 disables the mux of TCP in xray, sing-box has no equivalent
+	if m.Concurrency > 0 {
+		opts.MaxStreams = m.Concurrency
+	}
+	return opts
+}
@@ -0,0 +1,42 @@
+package singbox
+
+import (
+	"testing"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+)
+
+func TestVless_Multiplex(t *testing.T) {
+	v := NewVless("vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?mux=1&muxConcurrency=8&muxProtocol=h2mux&muxPadding=1#mux")
+	if err := v.Parse(); err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+
+	out, err := v.CraftOutboundOptions(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := out.VLESSOptions.Multiplex
+	if m == nil || !m.Enabled || m.Protocol != "h2mux" || m.MaxStreams != 8 || !m.Padding {
+		t.Errorf("unexpected multiplex options: %+v", m)
+	}
+
+	// sing-box has to accept them
+	if _, err = NewSingboxService(false, false).MakeInstance(v); err != nil {
+		t.Error(err)
+	}
+
+	// The global options apply to the links that don't set them
+	protocol.SetMux(protocol.Mux{Enabled: true, Protocol: "yamux"})
+	defer protocol.SetMux(protocol.Mux{})
+	tr := NewTrojan("trojan://pw@example.com:443?sni=example.com#default")
+	if err = tr.Parse(); err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+	if out, err = tr.CraftOutboundOptions(false); err != nil {
+		t.Fatal(err)
+	}
+	if m = out.TrojanOptions.Multiplex; m == nil || m.Protocol != "yamux" {
+		t.Errorf("unexpected multiplex options: %+v", m)
+	}
+}
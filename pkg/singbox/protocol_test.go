@@ -0,0 +1,63 @@
+package singbox
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+)
+
+func TestBuild(t *testing.T) {
+	link := "tuic://0090bbba-1118-46ca-87a1-52599cee74ab:pw@example.com:443?congestion_control=bbr&alpn=h3&sni=example.com#tuic"
+	p, err := protocol.CreateProtocol(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	built, err := Build(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The core shares the parsed fields instead of parsing the link again
+	if built.(*Tuic).Tuic != p || built.DetailsStr() != p.DetailsStr() {
+		t.Errorf("built protocol differs from the parsed one: %+v", built)
+	}
+
+	// A protocol of the core is built as it is
+	if again, err := Build(built); err != nil || again != built {
+		t.Errorf("expected the built protocol back, got %+v, %v", again, err)
+	}
+}
+
+func TestBuild_KeepsLink(t *testing.T) {
+	links := []string{
+		"vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?flow=xtls-rprx-vision&security=tls&sni=example.com&type=grpc&serviceName=%2Fsvc#vision",
+		"trojan://secret@example.com:443?security=tls&sni=example.com&type=grpc&serviceName=%2Fsvc#trojan",
+		"vmess://" + base64.StdEncoding.EncodeToString([]byte(`{"v":"2","ps":"grpc","add":"example.com","port":"443","id":"0090bbba-1118-46ca-87a1-52599cee74ab","aid":"0","net":"grpc","path":"/svc","tls":"tls","sni":"example.com"}`)),
+	}
+	for _, link := range links {
+		p, err := protocol.CreateProtocol(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = p.Parse(); err != nil {
+			t.Fatalf("%s: %v", link, err)
+		}
+		before := p.ToLink()
+
+		built, err := Build(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = built.CraftOutboundOptions(false); err != nil {
+			t.Fatalf("%s: %v", link, err)
+		}
+		// The defaults of the core don't leak into the shared config
+		if after := p.ToLink(); after != before {
+			t.Errorf("link changed by the build\nbefore: %s\nafter:  %s", before, after)
+		}
+	}
+}
@@ -9,138 +9,120 @@ import (
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-box/outbound"
 	"github.com/sagernet/sing/common/logger"
-	"net"
-	"net/url"
 	"strconv"
 	"strings"
-
-	"github.com/fatih/color"
-	"github.com/naser-989/xray-knife/v3/utils"
 )
 
 func NewShadowsocks(link string) Protocol {
-	return &Shadowsocks{OrigLink: link}
+	return &Shadowsocks{protocol.NewShadowsocks(link)}
 }
 
-func (s *Shadowsocks) Name() string {
-	return protocol.ShadowsocksIdentifier
+func (s *Shadowsocks) CraftInboundOptions() *option.Inbound {
+	return &option.Inbound{
+		Type: "shadowsocks",
+	}
 }
 
-func (s *Shadowsocks) Parse() error {
-	if !strings.HasPrefix(s.OrigLink, protocol.ShadowsocksIdentifier) {
-		return fmt.Errorf("shadowsocks unreconized: %s", s.OrigLink)
-	}
+func (s *Shadowsocks) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, error) {
+	port, _ := strconv.Atoi(s.Port)
 
-	uri, err := url.Parse(s.OrigLink)
-	if err != nil {
-		return err
+	opts := option.ShadowsocksOutboundOptions{
+		DialerOptions: option.DialerOptions{},
+		ServerOptions: option.ServerOptions{
+			Server:     s.Address,
+			ServerPort: uint16(port),
+		},
+		Password:  s.Password,
+		Method:    s.Encryption,
+		Multiplex: multiplexOptions(protocol.MuxOf(s.Shadowsocks)),
 	}
 
-	secondPart := strings.SplitN(s.OrigLink[5:], "@", 2)
-
-	var decoded []byte
-	// Encryption part - b64 encoded (EncryptionType : Password)
-	if len(secondPart) > 1 {
-		decoded, err = utils.Base64Decode(secondPart[0])
-		if err != nil {
-			return errors.New("Error when decoding secret part ")
-		}
-	} else {
-		return errors.New("Invalid config link ")
+	switch s.Plugin {
+	case "":
+	case "obfs-local", "simple-obfs":
+		opts.Plugin = "obfs-local"
+		opts.PluginOptions = s.PluginOpts
+	case "v2ray-plugin":
+		opts.Plugin = s.Plugin
+		opts.PluginOptions = s.PluginOpts
+	case "shadow-tls":
+		// Served by the detour outbound, see CraftDetourOutboundOptions
+	default:
+		return nil, fmt.Errorf("shadowsocks plugin %q is not supported by sing-box core", s.Plugin)
 	}
 
-	//link := "ss://" + string(decoded) + "@" + secondPart[1]
-	//uri, err := url.Parse(link)
-	//if err != nil {
-	//	return err
-	//}
-	creds := strings.SplitN(string(decoded), ":", 2)
-	if len(creds) != 2 {
-		return errors.New("error when decoding secret part")
-	}
+	return &option.Outbound{
+		Type:               "shadowsocks",
+		ShadowsocksOptions: opts,
+	}, nil
+}
 
-	s.Encryption = creds[0] // Encryption Type
-	s.Password = creds[1]   // Encryption Password
+// CraftDetourOutboundOptions crafts the ShadowTLS outbound that the shadowsocks
+// outbound dials through. It returns nil when no ShadowTLS plugin is set.
+func (s *Shadowsocks) CraftDetourOutboundOptions(allowInsecure bool) (*option.Outbound, error) {
+	if s.Plugin != "shadow-tls" {
+		return nil, nil
+	}
 
-	//hostPortRemark := strings.SplitN(secondPart[1], ":", 2)
+	pluginOpts := protocol.ParsePluginOpts(s.PluginOpts)
 
-	s.Address, s.Port, err = net.SplitHostPort(uri.Host)
-	if err != nil {
-		return err
+	version := 3
+	if v, ok := pluginOpts["version"]; ok {
+		var err error
+		version, err = strconv.Atoi(v)
+		if err != nil || version < 1 || version > 3 {
+			return nil, fmt.Errorf("invalid shadow-tls version %q", v)
+		}
 	}
 
-	if utils.IsIPv6(s.Address) {
-		s.Address = "[" + s.Address + "]"
+	password := pluginOpts["password"]
+	if password == "" {
+		password = pluginOpts["passwd"]
 	}
-
-	s.Remark, err = url.PathUnescape(uri.Fragment)
-	if err != nil {
-		s.Remark = uri.Fragment
+	if password == "" && version > 1 {
+		return nil, errors.New("shadow-tls password is missing")
 	}
 
-	//s.Address = hostPortRemark[0]
-	//
-	//PortRemark := strings.SplitN(hostPortRemark[1], "#", 2)
-	//s.Port = PortRemark[0]
-
-	//remarkStr, _, _ := strings.Cut(PortRemark[1], "\n")
-
-	//s.Remark, err = url.PathUnescape(remarkStr)
-	//if err != nil {
-	//	s.Remark = remarkStr
-	//}
-
-	return nil
-}
-
-func (s *Shadowsocks) DetailsStr() string {
-	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %v\n%s: %s\n%s: %s\n",
-		color.RedString("Protocol"), s.Name(),
-		color.RedString("Remark"), s.Remark,
-		color.RedString("IP"), s.Address,
-		color.RedString("Port"), s.Port,
-		color.RedString("Encryption"), s.Encryption,
-		color.RedString("Password"), s.Password)
-	return info
-}
-
-func (s *Shadowsocks) ConvertToGeneralConfig() (g protocol.GeneralConfig) {
-	g.Protocol = s.Name()
-	g.Address = s.Address
-	g.ID = s.Password
-	g.Port = s.Port
-	g.Remark = s.Remark
-	g.OrigLink = s.OrigLink
-
-	return g
-}
-
-func (s *Shadowsocks) CraftInboundOptions() *option.Inbound {
-	return &option.Inbound{
-		Type: "shadowsocks",
+	host := pluginOpts["host"]
+	if host == "" {
+		return nil, errors.New("shadow-tls host is missing")
 	}
-}
 
-func (s *Shadowsocks) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, error) {
 	port, _ := strconv.Atoi(s.Port)
 
-	opts := option.ShadowsocksOutboundOptions{
+	opts := option.ShadowTLSOutboundOptions{
 		DialerOptions: option.DialerOptions{},
 		ServerOptions: option.ServerOptions{
-			Server:     s.Address,
+			Server:     strings.Trim(s.Address, "[]"),
 			ServerPort: uint16(port),
 		},
-		Password: s.Password,
-		Method:   s.Encryption,
+		Version:  version,
+		Password: password,
+		OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+			TLS: &option.OutboundTLSOptions{
+				Enabled:    true,
+				ServerName: host,
+				Insecure:   allowInsecure,
+			},
+		},
+	}
+	if fp := pluginOpts["fp"]; fp != "" {
+		opts.TLS.UTLS = &option.OutboundUTLSOptions{
+			Enabled:     true,
+			Fingerprint: fp,
+		}
 	}
 
 	return &option.Outbound{
-		Type:               "shadowsocks",
-		ShadowsocksOptions: opts,
+		Type:             "shadowtls",
+		ShadowTLSOptions: opts,
 	}, nil
 }
 
 func (s *Shadowsocks) CraftOutbound(ctx context.Context, l logger.ContextLogger, allowInsecure bool) (adapter.Outbound, error) {
+	if s.Plugin == "shadow-tls" {
+		return nil, errors.New("shadow-tls needs a chained outbound, craft it with CraftChainOptions")
+	}
 
 	options, err := s.CraftOutboundOptions(allowInsecure)
 	if err != nil {
@@ -0,0 +1,46 @@
+package singbox
+
+import (
+	"testing"
+)
+
+func TestShadowsocks_Plugin(t *testing.T) {
+	tests := []struct {
+		link       string
+		plugin     string
+		pluginOpts string
+	}{
+		{"ss://YWVzLTI1Ni1nY206c2VjcmV0@1.2.3.4:8388/?plugin=obfs-local%3Bobfs%3Dhttp%3Bobfs-host%3Dwww.bing.com#obfs", "obfs-local", "obfs=http;obfs-host=www.bing.com"},
+		{"ss://YWVzLTI1Ni1nY206c2VjcmV0@1.2.3.4:8388/?plugin=simple-obfs%3Bobfs%3Dtls#simple-obfs", "obfs-local", "obfs=tls"},
+		{"ss://YWVzLTI1Ni1nY206c2VjcmV0@example.com:443/?plugin=v2ray-plugin%3Bmode%3Dwebsocket%3Btls%3Bhost%3Dcdn.example.com#v2ray-plugin", "v2ray-plugin", "mode=websocket;tls;host=cdn.example.com"},
+	}
+
+	s := NewSingboxService(false, false)
+	for _, test := range tests {
+		ss := NewShadowsocks(test.link)
+		if err := ss.Parse(); err != nil {
+			t.Fatalf("%s: %v", test.link, err)
+		}
+
+		out, err := ss.CraftOutboundOptions(false)
+		if err != nil {
+			t.Fatalf("%s: %v", test.link, err)
+		}
+		if out.ShadowsocksOptions.Plugin != test.plugin || out.ShadowsocksOptions.PluginOptions != test.pluginOpts {
+			t.Errorf("%s: unexpected plugin %q (%q)", test.link, out.ShadowsocksOptions.Plugin, out.ShadowsocksOptions.PluginOptions)
+		}
+
+		// sing-box has to accept the plugin options as they are
+		if _, err = s.MakeInstance(ss); err != nil {
+			t.Errorf("%s: %v", test.link, err)
+		}
+	}
+
+	ss := NewShadowsocks("ss://YWVzLTI1Ni1nY206c2VjcmV0@1.2.3.4:8388/?plugin=kcptun#kcp")
+	if err := ss.Parse(); err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+	if _, err := ss.CraftOutboundOptions(false); err == nil {
+		t.Errorf("expected an unsupported plugin error")
+	}
+}
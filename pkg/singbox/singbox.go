@@ -2,6 +2,7 @@ package singbox
 
 import (
 	"context"
+	"fmt"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
 	box "github.com/sagernet/sing-box"
 	"github.com/sagernet/sing-box/log"
@@ -27,6 +28,20 @@ func (c *Core) Name() string {
 	return "singbox"
 }
 
+// capabilities are the protocols and transports the outbound builders handle
+var capabilities = protocol.Capabilities{
+	Core: "singbox",
+	Schemes: []string{protocol.VmessIdentifier, protocol.VlessIdentifier, protocol.ShadowsocksIdentifier, protocol.TrojanIdentifier,
+		protocol.SocksIdentifier, protocol.WireguardIdentifier, protocol.HttpIdentifier, protocol.HttpsIdentifier,
+		protocol.HysteriaIdentifier, protocol.Hysteria2Identifier, "hy2", protocol.TuicIdentifier},
+	Transports: []string{"tcp", "ws", "grpc", "http", "httpupgrade", "quic"},
+	Security:   []string{"none", "tls", "reality"},
+}
+
+func (c *Core) Capabilities() protocol.Capabilities {
+	return capabilities
+}
+
 type ServiceOption = func(c *Core)
 
 func WithInbound(inbound protocol.Protocol) ServiceOption {
@@ -88,24 +103,41 @@ func (f *FakeInstance) Close() error {
 }
 
 func (c *Core) SetInbound(inbound protocol.Protocol) error {
-	i := inbound.(Protocol)
+	i, err := Build(inbound)
+	if err != nil {
+		return err
+	}
 	c.Inbound = i.CraftInboundOptions()
 	return nil
 }
 
 func (c *Core) MakeInstance(outbound protocol.Protocol) (protocol.Instance, error) {
-	out := outbound.(Protocol)
+	out, err := Build(outbound)
+	if err != nil {
+		return nil, err
+	}
+	if err = capabilities.Check(out); err != nil {
+		return nil, err
+	}
 
-	outOpts, err := out.CraftOutboundOptions(c.AllowInsecure)
+	// The first outbound is the default one, the rest are its detours
+	outbounds, err := CraftChainOptions(out, "proxy", c.AllowInsecure)
 	if err != nil {
 		return nil, err
 	}
 
+	var inbounds []option.Inbound
+	if c.Inbound != nil {
+		inbounds = append(inbounds, *c.Inbound)
+	}
+
+	return c.newBox(inbounds, outbounds)
+}
+
+func (c *Core) newBox(inbounds []option.Inbound, outbounds []option.Outbound) (*box.Box, error) {
 	opts := option.Options{
-		Inbounds: []option.Inbound{},
-		Outbounds: []option.Outbound{
-			*outOpts,
-		},
+		Inbounds:  inbounds,
+		Outbounds: outbounds,
 		Log: &option.LogOptions{
 			Disabled: true,
 		},
@@ -118,10 +150,6 @@ func (c *Core) MakeInstance(outbound protocol.Protocol) (protocol.Instance, erro
 		}
 	}
 
-	if c.Inbound != nil {
-		opts.Inbounds = append(opts.Inbounds, *c.Inbound)
-	}
-
 	singboxInstance, err := box.New(box.Options{
 		Options: opts,
 		Context: context.Background(),
@@ -135,7 +163,22 @@ func (c *Core) MakeInstance(outbound protocol.Protocol) (protocol.Instance, erro
 }
 
 func (c *Core) MakeHttpClient(outbound protocol.Protocol, maxDelay time.Duration) (*http.Client, protocol.Instance, error) {
-	out := outbound.(Protocol)
+	out, err := Build(outbound)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = capabilities.Check(out); err != nil {
+		return nil, nil, err
+	}
+
+	outbounds, err := CraftChainOptions(out, "proxy", c.AllowInsecure)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Chained outbounds find their detour through the router, so they need a whole box
+	if len(outbounds) > 1 {
+		return c.makeChainHttpClient(outbounds, maxDelay)
+	}
 
 	craftOutbound, err := out.CraftOutbound(context.Background(), c.Log, c.AllowInsecure)
 	if err != nil {
@@ -171,6 +214,35 @@ func (c *Core) MakeHttpClient(outbound protocol.Protocol, maxDelay time.Duration
 	}, &FakeInstance{}, nil
 }
 
+func (c *Core) makeChainHttpClient(outbounds []option.Outbound, maxDelay time.Duration) (*http.Client, protocol.Instance, error) {
+	instance, err := c.newBox(nil, outbounds)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = instance.Start(); err != nil {
+		instance.Close()
+		return nil, nil, err
+	}
+
+	craftOutbound, ok := instance.Router().Outbound(outbounds[0].Tag)
+	if !ok {
+		instance.Close()
+		return nil, nil, fmt.Errorf("outbound %s not found", outbounds[0].Tag)
+	}
+
+	tr := &http.Transport{
+		DisableKeepAlives: true,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return craftOutbound.DialContext(ctx, network, M.ParseSocksaddr(addr))
+		},
+	}
+
+	return &http.Client{
+		Transport: tr,
+		Timeout:   maxDelay,
+	}, instance, nil
+}
+
 //
 //func (c *Core) MakeDial() func(ctx context.Context, v *Instance, dest net.Destination) (net.Conn, error) {
 //
@@ -9,81 +9,14 @@ import (
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-box/outbound"
 	"github.com/sagernet/sing/common/logger"
-	"net"
 	"net/netip"
-	"net/url"
 	"strconv"
-	"strings"
 
-	"github.com/fatih/color"
-	"github.com/naser-989/xray-knife/v3/utils"
 	"github.com/xtls/xray-core/infra/conf"
 )
 
 func NewSocks(link string) Protocol {
-	return &Socks{OrigLink: link}
-}
-
-func (s *Socks) Name() string {
-	return protocol.SocksIdentifier
-}
-
-func (s *Socks) Parse() error {
-	if !strings.HasPrefix(s.OrigLink, protocol.SocksIdentifier) {
-		return fmt.Errorf("socks unreconized: %s", s.OrigLink)
-	}
-
-	var err error = nil
-
-	uri, err := url.Parse(s.OrigLink)
-	if err != nil {
-		return err
-	}
-	s.Remark = uri.Fragment
-	s.Address, s.Port, err = net.SplitHostPort(uri.Host)
-	if err != nil {
-		return err
-	}
-
-	if len(uri.User.String()) != 0 {
-		userB64, _ := utils.Base64Decode(uri.User.String())
-		creds := strings.Split(string(userB64), ":")
-		s.Username = creds[0]
-		s.Password = creds[1]
-	}
-
-	return err
-}
-
-func (s *Socks) DetailsStr() string {
-	copyV := *s
-
-	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %v\n",
-		color.RedString("Protocol"), s.Name(),
-		color.RedString("Remark"), copyV.Remark,
-		color.RedString("Network"), "tcp",
-		color.RedString("Address"), copyV.Address,
-		color.RedString("Port"), copyV.Port,
-	)
-
-	if len(copyV.Username) != 0 && len(copyV.Password) != 0 {
-		info += color.RedString("Username") + ": " + copyV.Username
-		info += "\n"
-		info += color.RedString("Password") + ": " + copyV.Password
-	}
-
-	return info
-}
-
-func (s *Socks) ConvertToGeneralConfig() (g protocol.GeneralConfig) {
-	g.Protocol = s.Name()
-	g.Address = s.Address
-	g.Port = fmt.Sprintf("%v", s.Port)
-	g.Remark = s.Remark
-
-	g.OrigLink = s.OrigLink
-
-	return g
+	return &Socks{protocol.NewSocks(link)}
 }
 
 func (s *Socks) BuildOutboundDetourConfig(allowInsecure bool) (*conf.OutboundDetourConfig, error) {
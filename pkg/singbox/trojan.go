@@ -4,192 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/fatih/color"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
-	"github.com/naser-989/xray-knife/v3/utils"
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-box/outbound"
 	"github.com/sagernet/sing/common/logger"
 	"github.com/xtls/xray-core/infra/conf"
-	"net"
-	"net/url"
-	"reflect"
 	"strconv"
 	"strings"
 )
 
 func NewTrojan(link string) Protocol {
-	return &Trojan{OrigLink: link}
-}
-
-func (t *Trojan) Name() string {
-	return "trojan"
-}
-
-func (t *Trojan) Parse() error {
-	if !strings.HasPrefix(t.OrigLink, protocol.TrojanIdentifier) {
-		return fmt.Errorf("trojan unreconized: %s", t.OrigLink)
-	}
-	uri, err := url.Parse(t.OrigLink)
-	if err != nil {
-		return err
-	}
-
-	t.Password = uri.User.String()
-	t.Address, t.Port, err = net.SplitHostPort(uri.Host)
-	if err != nil {
-		return err
-	}
-
-	if utils.IsIPv6(t.Address) {
-		t.Address = "[" + t.Address + "]"
-	}
-	// Get the type of the struct
-	structType := reflect.TypeOf(*t)
-
-	// Get the number of fields in the struct
-	numFields := structType.NumField()
-
-	// Iterate over each field of the struct
-	for i := 0; i < numFields; i++ {
-		field := structType.Field(i)
-		tag := field.Tag.Get("json")
-
-		// If the query value exists for the field, set it
-		if values, ok := uri.Query()[tag]; ok {
-			value := values[0]
-			v := reflect.ValueOf(t).Elem().FieldByName(field.Name)
-
-			switch v.Type().String() {
-			case "string":
-				v.SetString(value)
-			case "int":
-				var intValue int
-				fmt.Sscanf(value, "%d", &intValue)
-				v.SetInt(int64(intValue))
-			}
-		}
-	}
-
-	t.Remark, err = url.PathUnescape(uri.Fragment)
-	if err != nil {
-		t.Remark = uri.Fragment
-	}
-
-	if t.HeaderType == "http" || t.Type == "ws" || t.Type == "h2" {
-		if t.Path == "" {
-			t.Path = "/"
-		}
-	}
-
-	if t.Type == "" {
-		t.Type = "tcp"
-	}
-	if t.Security == "" {
-		t.Security = "tls"
-	}
-	if t.TlsFingerprint == "" {
-		t.TlsFingerprint = "chrome"
-	}
-
-	return nil
-}
-
-func (t *Trojan) DetailsStr() string {
-	copyV := *t
-	if copyV.Flow == "" || copyV.Type == "grpc" {
-		copyV.Flow = "none"
-	}
-	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %v\n%s: %s\n%s: %s\n",
-		color.RedString("Protocol"), t.Name(),
-		color.RedString("Remark"), t.Remark,
-		color.RedString("Network"), t.Type,
-		color.RedString("Address"), t.Address,
-		color.RedString("Port"), t.Port,
-		color.RedString("Password"), t.Password,
-		color.RedString("Flow"), copyV.Flow,
-	)
-
-	if copyV.Type == "" {
-
-	} else if copyV.Type == "http" || copyV.Type == "httpupgrade" || copyV.Type == "ws" || copyV.Type == "h2" || copyV.Type == "splithttp" {
-		info += fmt.Sprintf("%s: %s\n%s: %s\n",
-			color.RedString("Host"), copyV.Host,
-			color.RedString("Path"), copyV.Path)
-	} else if copyV.Type == "kcp" {
-		info += fmt.Sprintf("%s: %s\n", color.RedString("KCP Seed"), copyV.Path)
-	} else if copyV.Type == "grpc" {
-		if copyV.ServiceName == "" {
-			copyV.ServiceName = "none"
-		}
-		info += fmt.Sprintf("%s: %s\n", color.RedString("ServiceName"), copyV.ServiceName)
-	}
-
-	if copyV.Security == "reality" {
-		info += fmt.Sprintf("%s: reality\n", color.RedString("TLS"))
-		if copyV.SpiderX == "" {
-			copyV.SpiderX = "none"
-		}
-		info += fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %s\n",
-			color.RedString("Public key"), copyV.PublicKey,
-			color.RedString("SNI"), copyV.SNI,
-			color.RedString("ShortID"), copyV.ShortIds,
-			color.RedString("SpiderX"), copyV.SpiderX,
-			color.RedString("Fingerprint"), copyV.TlsFingerprint,
-		)
-	} else if copyV.Security == "tls" {
-		info += fmt.Sprintf("%s: tls\n", color.RedString("TLS"))
-		if len(copyV.SNI) == 0 {
-			if copyV.Host != "" {
-				copyV.SNI = copyV.Host
-			} else {
-				copyV.SNI = "none"
-			}
-		}
-		if len(copyV.ALPN) == 0 {
-			copyV.ALPN = "none"
-		}
-		if copyV.TlsFingerprint == "" {
-			copyV.TlsFingerprint = "none"
-		}
-		info += fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n",
-			color.RedString("SNI"), copyV.SNI,
-			color.RedString("ALPN"), copyV.ALPN,
-			color.RedString("Fingerprint"), copyV.TlsFingerprint)
-
-		if t.AllowInsecure != "" {
-			info += fmt.Sprintf("%s: %v\n",
-				color.RedString("Insecure"), t.AllowInsecure)
-		}
-	} else {
-		info += fmt.Sprintf("%s: none\n", color.RedString("TLS"))
-	}
-	return info
-}
-
-func (t *Trojan) ConvertToGeneralConfig() (g protocol.GeneralConfig) {
-	g.Protocol = t.Name()
-	g.Address = t.Address
-	g.Host = t.Host
-	g.ID = t.Password
-	g.Path = t.Path
-	g.Port = t.Port
-	g.Remark = t.Remark
-	g.SNI = t.SNI
-	g.ALPN = t.ALPN
-	if t.Security == "" {
-		g.TLS = "none"
-	} else {
-		g.TLS = t.Security
-	}
-	g.TlsFingerprint = t.TlsFingerprint
-	g.ServiceName = t.ServiceName
-	g.Mode = t.Mode
-	g.Type = t.Type
-	g.OrigLink = t.OrigLink
-
-	return g
+	return &Trojan{protocol.NewTrojan(link)}
 }
 
 func (t *Trojan) CraftInboundOptions() *option.Inbound {
@@ -231,8 +57,9 @@ func (t *Trojan) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, err
 	}
 
 	switch t.Type {
-	case "tcp":
-		break
+	case "", "tcp":
+		// Plain TCP has no V2Ray transport in sing-box
+		transport = nil
 	case "ws":
 		transport.WebsocketOptions = option.V2RayWebsocketOptions{
 			Path:                t.Path,
@@ -267,13 +94,8 @@ func (t *Trojan) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, err
 		break
 	case "grpc":
 		// t.Mode Gun & Multi
-		if len(t.ServiceName) > 0 {
-			if t.ServiceName[0] == '/' {
-				t.ServiceName = t.ServiceName[1:]
-			}
-		}
 		transport.GRPCOptions = option.V2RayGRPCOptions{
-			ServiceName: t.ServiceName,
+			ServiceName: strings.TrimPrefix(t.ServiceName, "/"),
 		}
 		break
 	case "quic":
@@ -289,6 +111,7 @@ func (t *Trojan) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, err
 		},
 		Password:  t.Password,
 		Transport: transport,
+		Multiplex: multiplexOptions(protocol.MuxOf(t.Trojan)),
 		OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
 			TLS: &option.OutboundTLSOptions{
 				Enabled:    tls,
@@ -0,0 +1,78 @@
+package singbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/outbound"
+	"github.com/sagernet/sing/common/logger"
+	"strconv"
+	"strings"
+)
+
+func NewTuic(link string) Protocol {
+	return &Tuic{protocol.NewTuic(link)}
+}
+
+func (t *Tuic) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, error) {
+	port, _ := strconv.Atoi(t.Port)
+	var insecure = allowInsecure
+
+	if t.Insecure == "1" || t.Insecure == "true" {
+		insecure = true
+	}
+
+	// TUIC servers only speak HTTP/3
+	alpn := []string{"h3"}
+	if t.ALPN != "" && t.ALPN != "none" {
+		alpn = strings.Split(t.ALPN, ",")
+	}
+
+	opts := option.TUICOutboundOptions{
+		DialerOptions: option.DialerOptions{},
+		ServerOptions: option.ServerOptions{
+			Server:     t.Address,
+			ServerPort: uint16(port),
+		},
+		UUID:              t.UUID,
+		Password:          t.Password,
+		CongestionControl: t.CongestionControl,
+		UDPRelayMode:      t.UDPRelayMode,
+		OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+			TLS: &option.OutboundTLSOptions{
+				Enabled:    true,
+				DisableSNI: t.DisableSNI == "1" || t.DisableSNI == "true",
+				ServerName: t.SNI,
+				Insecure:   insecure,
+				ALPN:       alpn,
+			},
+		},
+	}
+
+	return &option.Outbound{
+		Type:        t.Name(),
+		TUICOptions: opts,
+	}, nil
+}
+
+func (t *Tuic) CraftInboundOptions() *option.Inbound {
+	return &option.Inbound{
+		Type: t.Name(),
+	}
+}
+
+func (t *Tuic) CraftOutbound(ctx context.Context, l logger.ContextLogger, allowInsecure bool) (adapter.Outbound, error) {
+	options, err := t.CraftOutboundOptions(allowInsecure)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := outbound.New(ctx, adapter.RouterFromContext(ctx), l, "out_tuic", *options)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating tuic outbound: %v", err)
+	}
+
+	return out, nil
+}
@@ -4,189 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/fatih/color"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
-	"github.com/naser-989/xray-knife/v3/utils"
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-box/outbound"
 	"github.com/sagernet/sing/common/logger"
 	"github.com/xtls/xray-core/infra/conf"
-	"net"
-	"net/url"
-	"reflect"
 	"strconv"
 	"strings"
 )
 
 func NewVless(link string) Protocol {
-	return &Vless{OrigLink: link}
+	return &Vless{protocol.NewVless(link)}
 }
 
-func (v *Vless) Name() string {
-	return protocol.VlessIdentifier
-}
-
-func (v *Vless) Parse() error {
-	if !strings.HasPrefix(v.OrigLink, protocol.VlessIdentifier) {
-		return fmt.Errorf("vless unreconized: %s", v.OrigLink)
-	}
-
-	uri, err := url.Parse(v.OrigLink)
-	if err != nil {
-		return err
-	}
-
-	v.ID = uri.User.String()
-
-	v.Address, v.Port, err = net.SplitHostPort(uri.Host)
-	if err != nil {
-		return err
-	}
-
-	if utils.IsIPv6(v.Address) {
-		v.Address = "[" + v.Address + "]"
-	}
-
-	// Get the type of the struct
-	t := reflect.TypeOf(*v)
-
-	// Get the number of fields in the struct
-	numFields := t.NumField()
-
-	// Iterate over each field of the struct
-	for i := 0; i < numFields; i++ {
-		field := t.Field(i)
-		tag := field.Tag.Get("json")
-
-		// If the query value exists for the field, set it
-		if values, ok := uri.Query()[tag]; ok {
-			value := values[0]
-			v := reflect.ValueOf(v).Elem().FieldByName(field.Name)
-
-			switch v.Type().String() {
-			case "string":
-				v.SetString(value)
-			case "int":
-				var intValue int
-				fmt.Sscanf(value, "%d", &intValue)
-				v.SetInt(int64(intValue))
-			}
-		}
-	}
-
-	v.Remark, err = url.PathUnescape(uri.Fragment)
-	if err != nil {
-		v.Remark = uri.Fragment
-	}
-	//portUint, err := strconv.ParseUint(address[1], 10, 16)
-	//if err != nil {
-	//	fmt.Fprintf(os.Stderr, "%v", err)
-	//	os.Exit(1)
-	//}
-	//v.Port = uint16(portUint)
-
-	if v.HeaderType == "http" || v.Type == "ws" || v.Type == "h2" {
-		if v.Path == "" {
-			v.Path = "/"
-		}
-	}
-
-	return nil
-}
-
-func (v *Vless) DetailsStr() string {
-	copyV := *v
-	if copyV.Flow == "" || copyV.Type == "grpc" {
-		copyV.Flow = "none"
-	}
-	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %v\n%s: %s\n%s: %s\n",
-		color.RedString("Protocol"), v.Name(),
-		color.RedString("Remark"), v.Remark,
-		color.RedString("Network"), v.Type,
-		color.RedString("Address"), v.Address,
-		color.RedString("Port"), v.Port,
-		color.RedString("UUID"), v.ID,
-		color.RedString("Flow"), copyV.Flow)
-	if copyV.Type == "" {
-
-	} else if copyV.HeaderType == "http" || copyV.Type == "httpupgrade" || copyV.Type == "ws" || copyV.Type == "h2" || copyV.Type == "splithttp" {
-		info += fmt.Sprintf("%s: %s\n%s: %s\n",
-			color.RedString("Host"), copyV.Host,
-			color.RedString("Path"), copyV.Path)
-	} else if copyV.Type == "kcp" {
-		info += fmt.Sprintf("%s: %s\n", color.RedString("KCP Seed"), copyV.Path)
-	} else if copyV.Type == "grpc" {
-		if copyV.ServiceName == "" {
-			copyV.ServiceName = "none"
-		}
-		info += fmt.Sprintf("%s: %s\n", color.RedString("ServiceName"), copyV.ServiceName)
-	}
-
-	if copyV.Security == "reality" {
-		info += fmt.Sprintf("%s: reality\n", color.RedString("TLS"))
-		if copyV.SpiderX == "" {
-			copyV.SpiderX = "none"
-		}
-		info += fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %s\n",
-			color.RedString("Public key"), copyV.PublicKey,
-			color.RedString("SNI"), copyV.SNI,
-			color.RedString("ShortID"), copyV.ShortIds,
-			color.RedString("SpiderX"), copyV.SpiderX,
-			color.RedString("Fingerprint"), copyV.TlsFingerprint,
-		)
-	} else if copyV.Security == "tls" {
-		info += fmt.Sprintf("%s: tls\n", color.RedString("TLS"))
-		if len(copyV.SNI) == 0 {
-			if copyV.Host != "" {
-				copyV.SNI = copyV.Host
-			} else {
-				copyV.SNI = "none"
-			}
-		}
-		if len(copyV.ALPN) == 0 {
-			copyV.ALPN = "none"
-		}
-		if copyV.TlsFingerprint == "" {
-			copyV.TlsFingerprint = "none"
-		}
-		info += fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n",
-			color.RedString("SNI"), copyV.SNI,
-			color.RedString("ALPN"), copyV.ALPN,
-			color.RedString("Fingerprint"), copyV.TlsFingerprint)
-
-		if v.AllowInsecure != "" {
-			info += fmt.Sprintf("%s: %v\n",
-				color.RedString("Insecure"), v.AllowInsecure)
-		}
-	} else {
-		info += fmt.Sprintf("%s: none\n", color.RedString("TLS"))
-	}
-	return info
-}
-
-func (v *Vless) ConvertToGeneralConfig() (g protocol.GeneralConfig) {
-	g.Protocol = v.Name()
-	g.Address = v.Address
-	g.Host = v.Host
-	g.ID = v.ID
-	g.Path = v.Path
-	g.Port = v.Port
-	g.Remark = v.Remark
-	if v.Security == "" {
-		g.TLS = "none"
-	} else {
-		g.TLS = v.Security
-	}
-	g.SNI = v.SNI
-	g.ALPN = v.ALPN
-	g.TlsFingerprint = v.TlsFingerprint
-	g.ServiceName = v.ServiceName
-	g.Mode = v.Mode
-	g.Type = v.Type
-	g.OrigLink = v.OrigLink
-
-	return g
+func (v *Vless) Validate() protocol.Diagnostics {
+	// sing-box doesn't map strings to UUIDs like xray does
+	return v.Vless.Validate().Escalate("id")
 }
 
 func (v *Vless) CraftInboundOptions() *option.Inbound {
@@ -229,8 +63,9 @@ func (v *Vless) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, erro
 	}
 
 	switch v.Type {
-	case "tcp":
-		return nil, errors.New("tcp transport not supported")
+	case "", "tcp":
+		// Plain TCP has no V2Ray transport in sing-box
+		transport = nil
 	case "ws":
 		transport.WebsocketOptions = option.V2RayWebsocketOptions{
 			Path:                v.Path,
@@ -264,13 +99,8 @@ func (v *Vless) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, erro
 		break
 	case "grpc":
 		// v.Mode Gun & Multi
-		if len(v.ServiceName) > 0 {
-			if v.ServiceName[0] == '/' {
-				v.ServiceName = v.ServiceName[1:]
-			}
-		}
 		transport.GRPCOptions = option.V2RayGRPCOptions{
-			ServiceName: v.ServiceName,
+			ServiceName: strings.TrimPrefix(v.ServiceName, "/"),
 		}
 		break
 	case "quic":
@@ -298,7 +128,8 @@ func (v *Vless) CraftOutboundOptions(allowInsecure bool) (*option.Outbound, erro
 				Insecure: insecure,
 			},
 		},
-		Flow: v.Flow,
+		Flow:      v.Flow,
+		Multiplex: multiplexOptions(protocol.MuxOf(v.Vless)),
 	}
 	if v.Security == "reality" {
 		opts.TLS.Reality = &option.OutboundRealityOptions{
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/fatih/color"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/option"
@@ -12,96 +11,12 @@ import (
 	"github.com/sagernet/sing/common/logger"
 	"net"
 	"net/netip"
-	"net/url"
-	"reflect"
 	"strconv"
 	"strings"
 )
 
 func NewWireguard(link string) Protocol {
-	return &Wireguard{OrigLink: link}
-}
-
-func (w *Wireguard) Name() string {
-	return protocol.WireguardIdentifier
-}
-
-func (w *Wireguard) Parse() error {
-	if !strings.HasPrefix(w.OrigLink, protocol.WireguardIdentifier) {
-		return fmt.Errorf("wireguard unreconized: %s", w.OrigLink)
-	}
-
-	uri, err := url.Parse(w.OrigLink)
-	if err != nil {
-		return err
-	}
-
-	unescapedSecretKey, err0 := url.PathUnescape(uri.User.String())
-	if err0 != nil {
-		return err0
-	}
-
-	w.SecretKey = unescapedSecretKey
-
-	w.Endpoint = uri.Host
-
-	// Get the type of the struct
-	t := reflect.TypeOf(*w)
-
-	// Get the number of fields in the struct
-	numFields := t.NumField()
-
-	// Iterate over each field of the struct
-	for i := 0; i < numFields; i++ {
-		field := t.Field(i)
-		tag := field.Tag.Get("json")
-
-		// If the query value exists for the field, set it
-		if values, ok := uri.Query()[tag]; ok {
-			value := values[0]
-			v := reflect.ValueOf(w).Elem().FieldByName(field.Name)
-
-			switch v.Type().String() {
-			case "string":
-				v.SetString(value)
-			case "int32":
-				intValue, err := strconv.ParseInt(value, 10, 32)
-				if err != nil {
-					return fmt.Errorf("failed to parse int32 value: %w", err)
-				}
-				v.SetInt(intValue)
-
-			}
-		}
-	}
-
-	w.Remark, err = url.PathUnescape(uri.Fragment)
-	if err != nil {
-		w.Remark = uri.Fragment
-	}
-
-	return nil
-}
-
-func (w *Wireguard) DetailsStr() string {
-	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %d\n%s: %s\n%s: %v\n%s: %s\n",
-		color.RedString("Protocol"), w.Name(),
-		color.RedString("Remark"), w.Remark,
-		color.RedString("Endpoint"), w.Endpoint,
-		color.RedString("MTU"), w.Mtu,
-		color.RedString("Local Addresses"), w.LocalAddress,
-		color.RedString("Public Key"), w.PublicKey,
-		color.RedString("Secret Key"), w.SecretKey,
-	)
-
-	return info
-}
-
-func (w *Wireguard) ConvertToGeneralConfig() (g protocol.GeneralConfig) {
-	g.Protocol = w.Name()
-	g.Address = w.Endpoint
-
-	return g
+	return &Wireguard{protocol.NewWireguard(link)}
 }
 
 func (w *Wireguard) CraftInboundOptions() *option.Inbound {
@@ -124,8 +39,11 @@ func (w *Wireguard) CraftOutboundOptions(allowInsecure bool) (*option.Outbound,
 	var reserved = []uint8{0, 0, 0}
 	if w.Reserved != "" {
 		reservedList := strings.Split(w.Reserved, ",")
+		if len(reservedList) != len(reserved) {
+			return nil, errors.New("invalid reserved value")
+		}
 		for i, v := range reservedList {
-			num, err := strconv.ParseUint(v, 10, 8)
+			num, err := strconv.ParseUint(strings.TrimSpace(v), 10, 8)
 			if err != nil {
 				fmt.Println(err)
 				return nil, errors.New("invalid reserved value")
@@ -155,9 +73,9 @@ func (w *Wireguard) CraftOutboundOptions(allowInsecure bool) (*option.Outbound,
 		//},
 		PeerPublicKey: w.PublicKey,
 		PrivateKey:    w.SecretKey,
-		//PreSharedKey: w.SecretKey,
-		Reserved: reserved,
-		MTU:      uint32(w.Mtu),
+		PreSharedKey:  w.PreSharedKey,
+		Reserved:      reserved,
+		MTU:           uint32(w.Mtu),
 	}
 
 	localAddresses := strings.Split(w.LocalAddress, ",")
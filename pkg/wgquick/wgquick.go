@@ -0,0 +1,240 @@
+package wgquick
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+)
+
+var interfaceSection = regexp.MustCompile(`(?mi)^\s*\[Interface\]\s*$`)
+
+// Interface is the [Interface] section of a wg-quick config
+type Interface struct {
+	PrivateKey string
+	Addresses  []string
+	MTU        int
+	Reserved   []int // Not part of wg-quick, added by the WARP tools
+}
+
+// Peer is a [Peer] section of a wg-quick config
+type Peer struct {
+	PublicKey    string
+	PresharedKey string
+	Endpoint     string
+	AllowedIPs   []string
+	Reserved     []int
+}
+
+// Config is a parsed wg-quick config
+type Config struct {
+	Interface Interface
+	Peers     []Peer
+}
+
+// IsConfig reports whether data looks like a wg-quick INI config (wg0.conf)
+func IsConfig(data []byte) bool {
+	return interfaceSection.Match(data)
+}
+
+// Parse reads a wg-quick config. Keys are case-insensitive and
+// the ones that don't matter for an outbound (DNS, Table, PostUp, ...) are ignored.
+func Parse(data []byte) (*Config, error) {
+	config := &Config{}
+	var peer *Peer
+	section := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			if section == "peer" {
+				config.Peers = append(config.Peers, Peer{})
+				peer = &config.Peers[len(config.Peers)-1]
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch section {
+		case "interface":
+			switch key {
+			case "privatekey":
+				config.Interface.PrivateKey = value
+			case "address":
+				config.Interface.Addresses = append(config.Interface.Addresses, splitList(value)...)
+			case "mtu":
+				config.Interface.MTU, err = strconv.Atoi(value)
+			case "reserved":
+				config.Interface.Reserved, err = parseReserved(value)
+			}
+		case "peer":
+			switch key {
+			case "publickey":
+				peer.PublicKey = value
+			case "presharedkey":
+				peer.PresharedKey = value
+			case "endpoint":
+				peer.Endpoint = value
+			case "allowedips":
+				peer.AllowedIPs = append(peer.AllowedIPs, splitList(value)...)
+			case "reserved":
+				peer.Reserved, err = parseReserved(value)
+			}
+		case "":
+			return nil, fmt.Errorf("line %d: key outside of [Interface] or [Peer]", n)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid %s: %v", n, key, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if config.Interface.PrivateKey == "" {
+		return nil, errors.New("[Interface] has no PrivateKey")
+	}
+	if len(config.Peers) == 0 {
+		return nil, errors.New("config has no [Peer]")
+	}
+
+	return config, nil
+}
+
+// LoadPeers turns every [Peer] of a wg-quick config into a Wireguard outbound
+// sharing the [Interface] keys and addresses.
+// name (usually the config file name) is used as the remark, numbered when there are several peers.
+func LoadPeers(data []byte, name string) ([]protocol.Protocol, []error) {
+	config, err := Parse(data)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var protocols []protocol.Protocol
+	var errs []error
+	for i := range config.Peers {
+		remark := name
+		if len(config.Peers) > 1 {
+			remark = fmt.Sprintf("%s-%d", name, i+1)
+		}
+
+		w, err := config.Wireguard(i, remark)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("peer #%d: %v", i+1, err))
+			continue
+		}
+		protocols = append(protocols, w)
+	}
+
+	return protocols, errs
+}
+
+// Wireguard builds the outbound of the i-th peer.
+// The reserved bytes of the peer take precedence over the ones of the interface.
+func (c *Config) Wireguard(i int, remark string) (*protocol.Wireguard, error) {
+	peer := c.Peers[i]
+	if peer.PublicKey == "" {
+		return nil, errors.New("peer has no PublicKey")
+	}
+	if peer.Endpoint == "" {
+		return nil, errors.New("peer has no Endpoint")
+	}
+
+	var addresses []string
+	for _, address := range c.Interface.Addresses {
+		addresses = append(addresses, withPrefix(address))
+	}
+
+	reserved := c.Interface.Reserved
+	if len(peer.Reserved) != 0 {
+		reserved = peer.Reserved
+	}
+	var reservedValues []string
+	for _, v := range reserved {
+		reservedValues = append(reservedValues, strconv.Itoa(v))
+	}
+
+	w := &protocol.Wireguard{
+		Remark:       remark,
+		PublicKey:    peer.PublicKey,
+		SecretKey:    c.Interface.PrivateKey,
+		PreSharedKey: peer.PresharedKey,
+		Endpoint:     peer.Endpoint,
+		Reserved:     strings.Join(reservedValues, ","),
+		LocalAddress: strings.Join(addresses, ","),
+		Mtu:          int32(c.Interface.MTU),
+	}
+
+	w.OrigLink = w.ToLink()
+	return w, nil
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// withPrefix appends the host prefix length to a bare address
+func withPrefix(address string) string {
+	if strings.Contains(address, "/") {
+		return address
+	}
+	if strings.Contains(address, ":") {
+		return address + "/128"
+	}
+	return address + "/32"
+}
+
+// parseReserved accepts the reserved bytes as a list ("1, 2, 3" or "[1, 2, 3]") or base64 ("AQID")
+func parseReserved(value string) ([]int, error) {
+	value = strings.Trim(value, "[]")
+
+	var reserved []int
+	if !strings.Contains(value, ",") {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range decoded {
+			reserved = append(reserved, int(b))
+		}
+	} else {
+		for _, v := range splitList(value) {
+			num, err := strconv.ParseUint(v, 10, 8)
+			if err != nil {
+				return nil, err
+			}
+			reserved = append(reserved, int(num))
+		}
+	}
+
+	if len(reserved) != 3 {
+		return nil, fmt.Errorf("expected 3 bytes, got %d", len(reserved))
+	}
+	return reserved, nil
+}
@@ -0,0 +1,98 @@
+package wgquick
+
+import (
+	"testing"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/naser-989/xray-knife/v3/pkg/singbox"
+	"github.com/naser-989/xray-knife/v3/pkg/xray"
+)
+
+func TestLoadPeers(t *testing.T) {
+	config := `
+# Generated by a WARP client
+[Interface]
+PrivateKey = eCtXsJZ27+4PbhDkHnB923tkUn2Gj59wZw5wFA75MnU=
+Address = 172.16.0.2/32, 2606:4700:110:8f81:d551:a0:532e:a2b3
+DNS = 1.1.1.1
+MTU = 1280
+Reserved = AQID
+
+[Peer]
+PublicKey = bmXOC+F1FxEMF9dyiK2H5/1SUtzH0JuVo51h2wPfgyo=
+AllowedIPs = 0.0.0.0/0, ::/0
+Endpoint = 162.159.192.1:2408
+
+[peer]
+publickey = bmXOC+F1FxEMF9dyiK2H5/1SUtzH0JuVo51h2wPfgyo=
+PresharedKey = ZGvbqw0qGXRCvYjYdYuxdM6ZpAxRzDr3eYUf6WqXxHs=
+Endpoint = [2606:4700:d0::a29f:c001]:2408
+Reserved = [98, 233, 215] # Peer reserved bytes win
+
+[Peer]
+PublicKey = bmXOC+F1FxEMF9dyiK2H5/1SUtzH0JuVo51h2wPfgyo=
+`
+	if !IsConfig([]byte(config)) {
+		t.Fatal("config not detected as a wg-quick config")
+	}
+
+	protocols, errs := LoadPeers([]byte(config), "warp")
+	if len(protocols) != 2 {
+		t.Fatalf("expected 2 peers, got %d (errors: %v)", len(protocols), errs)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected the peer without endpoint to be reported, got %v", errs)
+	}
+
+	first := protocols[0].(*protocol.Wireguard)
+	if first.Remark != "warp-1" || first.Endpoint != "162.159.192.1:2408" || first.Reserved != "1,2,3" || first.Mtu != 1280 ||
+		first.LocalAddress != "172.16.0.2/32,2606:4700:110:8f81:d551:a0:532e:a2b3/128" {
+		t.Errorf("unexpected first peer: %+v", first)
+	}
+
+	second := protocols[1].(*protocol.Wireguard)
+	if second.Remark != "warp-2" || second.Endpoint != "[2606:4700:d0::a29f:c001]:2408" || second.Reserved != "98,233,215" || second.PreSharedKey == "" {
+		t.Errorf("unexpected second peer: %+v", second)
+	}
+
+	// The links have to work with both cores
+	for _, p := range protocols {
+		link := p.ToLink()
+
+		sw := singbox.NewWireguard(link)
+		if err := sw.Parse(); err != nil {
+			t.Fatalf("%s: %v", link, err)
+		}
+		out, err := sw.CraftOutboundOptions(false)
+		if err != nil {
+			t.Fatalf("%s: %v", link, err)
+		}
+		if out.WireGuardOptions.Reserved[0] == 0 {
+			t.Errorf("%s: reserved bytes were lost", link)
+		}
+
+		xw := xray.NewWireguard(link)
+		if err = xw.Parse(); err != nil {
+			t.Fatalf("%s: %v", link, err)
+		}
+		detour, err := xw.BuildOutboundDetourConfig(false)
+		if err != nil {
+			t.Fatalf("%s: %v", link, err)
+		}
+		if _, err = detour.Build(); err != nil {
+			t.Errorf("%s: %v", link, err)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, config := range []string{
+		"[Peer]\nPublicKey = bmXOC+F1FxEMF9dyiK2H5/1SUtzH0JuVo51h2wPfgyo=\n",
+		"[Interface]\nPrivateKey = eCtXsJZ27+4PbhDkHnB923tkUn2Gj59wZw5wFA75MnU=\n",
+		"[Interface]\nPrivateKey = eCtXsJZ27+4PbhDkHnB923tkUn2Gj59wZw5wFA75MnU=\nReserved = 1, 2\n[Peer]\n",
+	} {
+		if _, err := Parse([]byte(config)); err == nil {
+			t.Errorf("expected an error for %q", config)
+		}
+	}
+}
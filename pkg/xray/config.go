@@ -0,0 +1,493 @@
+package xray
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/xtls/xray-core/infra/conf"
+	xjson "github.com/xtls/xray-core/infra/conf/json"
+)
+
+// LoadOutbounds reads a full xray-core JSON config and turns every entry of its
+// "outbounds" array into the matching protocol struct.
+// Outbounds that can't be mapped (freedom, blackhole, dns, ...) are skipped and
+// reported in the returned error slice.
+func LoadOutbounds(data []byte) ([]Protocol, []error) {
+	// Only the outbounds matter here, the rest of the config is ignored
+	var config struct {
+		OutboundConfigs []conf.OutboundDetourConfig `json:"outbounds"`
+	}
+	// xjson.Reader strips the comments that xray allows in its config files
+	if err := json.NewDecoder(&xjson.Reader{Reader: bytes.NewReader(data)}).Decode(&config); err != nil {
+		return nil, []error{err}
+	}
+
+	var protocols []Protocol
+	var errs []error
+	for i := range config.OutboundConfigs {
+		out := &config.OutboundConfigs[i]
+		p, err := NewFromOutbound(out)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("outbound #%d (%s, tag %q): %v", i+1, out.Protocol, out.Tag, err))
+			continue
+		}
+		protocols = append(protocols, p)
+	}
+
+	return protocols, errs
+}
+
+// NewFromOutbound converts a single xray outbound into its protocol struct.
+// The OrigLink of the result is set to its share link.
+func NewFromOutbound(out *conf.OutboundDetourConfig) (Protocol, error) {
+	if out.Settings == nil {
+		return nil, errors.New("outbound has no settings")
+	}
+	s := flattenStreamSettings(out.StreamSetting)
+
+	var p protocol.Protocol
+	var err error
+	switch out.Protocol {
+	case "vmess":
+		p, err = vmessFromOutbound(out, s)
+	case "vless":
+		p, err = vlessFromOutbound(out, s)
+	case "trojan":
+		p, err = trojanFromOutbound(out, s)
+	case "shadowsocks":
+		p, err = shadowsocksFromOutbound(out)
+	case "socks":
+		p, err = socksFromOutbound(out)
+	case "http":
+		p, err = httpFromOutbound(out, s)
+	case "wireguard":
+		p, err = wireguardFromOutbound(out)
+	default:
+		return nil, fmt.Errorf("unsupported outbound protocol %q", out.Protocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	setOrigLink(p)
+	return Build(p)
+}
+
+// setOrigLink fills the OrigLink of a protocol built from a JSON config
+func setOrigLink(p protocol.Protocol) {
+	link := p.ToLink()
+	switch v := p.(type) {
+	case *protocol.Vmess:
+		v.OrigLink = link
+	case *protocol.Vless:
+		v.OrigLink = link
+	case *protocol.Trojan:
+		v.OrigLink = link
+	case *protocol.Shadowsocks:
+		v.OrigLink = link
+	case *protocol.Socks:
+		v.OrigLink = link
+	case *protocol.Http:
+		v.OrigLink = link
+	case *protocol.Wireguard:
+		v.OrigLink = link
+	}
+}
+
+// Settings of the outbounds that use the vnext/servers layout
+type outboundUser struct {
+	ID         string      `json:"id"`
+	AlterID    interface{} `json:"alterId"`
+	Security   string      `json:"security"`
+	Flow       string      `json:"flow"`
+	Encryption string      `json:"encryption"`
+	User       string      `json:"user"`
+	Pass       string      `json:"pass"`
+}
+
+type outboundServer struct {
+	Address  string         `json:"address"`
+	Port     interface{}    `json:"port"`
+	Password string         `json:"password"`
+	Method   string         `json:"method"`
+	Flow     string         `json:"flow"`
+	Users    []outboundUser `json:"users"`
+}
+
+type outboundSettings struct {
+	Vnext   []outboundServer `json:"vnext"`
+	Servers []outboundServer `json:"servers"`
+}
+
+func firstServer(out *conf.OutboundDetourConfig) (*outboundServer, error) {
+	var settings outboundSettings
+	if err := json.Unmarshal(*out.Settings, &settings); err != nil {
+		return nil, err
+	}
+
+	servers := settings.Vnext
+	if len(servers) == 0 {
+		servers = settings.Servers
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("outbound has no server")
+	}
+	return &servers[0], nil
+}
+
+func portString(port interface{}) string {
+	switch p := port.(type) {
+	case float64:
+		return strconv.Itoa(int(p))
+	case string:
+		return p
+	default:
+		return ""
+	}
+}
+
+// streamFields is streamSettings flattened into the share link parameters
+type streamFields struct {
+	Network     string
+	Security    string
+	HeaderType  string
+	Host        string
+	Path        string
+	SNI         string
+	ALPN        string
+	Fingerprint string
+	Insecure    bool
+	PublicKey   string
+	ShortID     string
+	SpiderX     string
+	ServiceName string
+	Authority   string
+	Mode        string
+}
+
+func flattenStreamSettings(s *conf.StreamConfig) (f streamFields) {
+	f.Network = "tcp"
+	if s == nil {
+		return f
+	}
+
+	if s.Network != nil && *s.Network != "" {
+		f.Network = strings.ToLower(string(*s.Network))
+	}
+	if f.Network == "raw" {
+		f.Network = "tcp"
+	}
+	f.Security = s.Security
+
+	switch f.Network {
+	case "tcp":
+		tcp := s.TCPSettings
+		if tcp == nil {
+			tcp = s.RAWSettings
+		}
+		if tcp != nil && len(tcp.HeaderConfig) != 0 {
+			var header struct {
+				Type    string `json:"type"`
+				Request struct {
+					Path    conf.StringList            `json:"path"`
+					Headers map[string]conf.StringList `json:"headers"`
+				} `json:"request"`
+			}
+			if json.Unmarshal(tcp.HeaderConfig, &header) == nil {
+				f.HeaderType = header.Type
+				f.Path = strings.Join(header.Request.Path, ",")
+				f.Host = strings.Join(header.Request.Headers["Host"], ",")
+			}
+		}
+	case "kcp", "mkcp":
+		f.Network = "kcp"
+		if s.KCPSettings != nil {
+			var header struct {
+				Type string `json:"type"`
+			}
+			if json.Unmarshal(s.KCPSettings.HeaderConfig, &header) == nil {
+				f.HeaderType = header.Type
+			}
+			if s.KCPSettings.Seed != nil {
+				f.Path = *s.KCPSettings.Seed
+			}
+		}
+	case "ws", "websocket":
+		f.Network = "ws"
+		if s.WSSettings != nil {
+			f.Path = s.WSSettings.Path
+			f.Host = s.WSSettings.Host
+			if f.Host == "" {
+				f.Host = s.WSSettings.Headers["Host"]
+			}
+		}
+	case "httpupgrade":
+		if s.HTTPUPGRADESettings != nil {
+			f.Path = s.HTTPUPGRADESettings.Path
+			f.Host = s.HTTPUPGRADESettings.Host
+		}
+	case "xhttp":
+		if s.XHTTPSettings != nil {
+			f.Path = s.XHTTPSettings.Path
+			f.Host = s.XHTTPSettings.Host
+			f.Mode = s.XHTTPSettings.Mode
+		}
+	case "splithttp":
+		if s.SplitHTTPSettings != nil {
+			f.Path = s.SplitHTTPSettings.Path
+			f.Host = s.SplitHTTPSettings.Host
+			f.Mode = s.SplitHTTPSettings.Mode
+		}
+	case "grpc", "gun":
+		f.Network = "grpc"
+		f.Mode = "gun"
+		if s.GRPCSettings != nil {
+			f.ServiceName = s.GRPCSettings.ServiceName
+			f.Authority = s.GRPCSettings.Authority
+			if s.GRPCSettings.MultiMode {
+				f.Mode = "multi"
+			}
+		}
+	}
+
+	if s.TLSSettings != nil && f.Security == "tls" {
+		f.SNI = s.TLSSettings.ServerName
+		f.Fingerprint = s.TLSSettings.Fingerprint
+		f.Insecure = s.TLSSettings.Insecure
+		if s.TLSSettings.ALPN != nil {
+			f.ALPN = strings.Join(*s.TLSSettings.ALPN, ",")
+		}
+	}
+	if s.REALITYSettings != nil && f.Security == "reality" {
+		f.SNI = s.REALITYSettings.ServerName
+		f.Fingerprint = s.REALITYSettings.Fingerprint
+		f.PublicKey = s.REALITYSettings.PublicKey
+		f.ShortID = s.REALITYSettings.ShortId
+		f.SpiderX = s.REALITYSettings.SpiderX
+	}
+
+	return f
+}
+
+func insecureString(insecure bool) string {
+	if insecure {
+		return "1"
+	}
+	return ""
+}
+
+func vmessFromOutbound(out *conf.OutboundDetourConfig, s streamFields) (protocol.Protocol, error) {
+	server, err := firstServer(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(server.Users) == 0 {
+		return nil, errors.New("vmess outbound has no user")
+	}
+	user := server.Users[0]
+
+	v := &protocol.Vmess{
+		Version:        "2",
+		Address:        server.Address,
+		Aid:            fmt.Sprintf("%v", user.AlterID),
+		Port:           portString(server.Port),
+		Security:       user.Security,
+		Host:           s.Host,
+		ID:             user.ID,
+		Network:        s.Network,
+		Path:           s.Path,
+		Remark:         out.Tag,
+		TLS:            s.Security,
+		AllowInsecure:  insecureString(s.Insecure),
+		SNI:            s.SNI,
+		ALPN:           s.ALPN,
+		TlsFingerprint: s.Fingerprint,
+		Type:           s.HeaderType,
+	}
+	if user.AlterID == nil {
+		v.Aid = "0"
+	}
+	if v.Security == "" {
+		v.Security = "auto"
+	}
+	switch s.Network {
+	case "xhttp", "splithttp":
+		v.Type = s.Mode
+	case "grpc":
+		v.Path = s.ServiceName
+		v.Host = s.Authority
+		v.Type = s.Mode
+	}
+
+	return v, nil
+}
+
+func vlessFromOutbound(out *conf.OutboundDetourConfig, s streamFields) (protocol.Protocol, error) {
+	server, err := firstServer(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(server.Users) == 0 {
+		return nil, errors.New("vless outbound has no user")
+	}
+	user := server.Users[0]
+
+	v := &protocol.Vless{
+		ID:             user.ID,
+		Address:        server.Address,
+		Encryption:     user.Encryption,
+		Flow:           user.Flow,
+		Security:       s.Security,
+		PublicKey:      s.PublicKey,
+		ShortIds:       s.ShortID,
+		SpiderX:        s.SpiderX,
+		HeaderType:     s.HeaderType,
+		Host:           s.Host,
+		Path:           s.Path,
+		Port:           portString(server.Port),
+		SNI:            s.SNI,
+		ALPN:           s.ALPN,
+		TlsFingerprint: s.Fingerprint,
+		AllowInsecure:  insecureString(s.Insecure),
+		Type:           s.Network,
+		Remark:         out.Tag,
+		Authority:      s.Authority,
+		ServiceName:    s.ServiceName,
+		Mode:           s.Mode,
+	}
+	if v.Encryption == "" {
+		v.Encryption = "none"
+	}
+
+	return v, nil
+}
+
+func trojanFromOutbound(out *conf.OutboundDetourConfig, s streamFields) (protocol.Protocol, error) {
+	server, err := firstServer(out)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &protocol.Trojan{
+		Password:       server.Password,
+		Address:        server.Address,
+		Flow:           server.Flow,
+		Security:       s.Security,
+		HeaderType:     s.HeaderType,
+		Host:           s.Host,
+		Path:           s.Path,
+		Port:           portString(server.Port),
+		SNI:            s.SNI,
+		ALPN:           s.ALPN,
+		TlsFingerprint: s.Fingerprint,
+		AllowInsecure:  insecureString(s.Insecure),
+		Type:           s.Network,
+		Remark:         out.Tag,
+		Authority:      s.Authority,
+		ServiceName:    s.ServiceName,
+		Mode:           s.Mode,
+		PublicKey:      s.PublicKey,
+		ShortIds:       s.ShortID,
+		SpiderX:        s.SpiderX,
+	}
+
+	return t, nil
+}
+
+func shadowsocksFromOutbound(out *conf.OutboundDetourConfig) (protocol.Protocol, error) {
+	server, err := firstServer(out)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protocol.Shadowsocks{
+		Address:    server.Address,
+		Port:       portString(server.Port),
+		Encryption: server.Method,
+		Password:   server.Password,
+		Remark:     out.Tag,
+	}, nil
+}
+
+func socksFromOutbound(out *conf.OutboundDetourConfig) (protocol.Protocol, error) {
+	server, err := firstServer(out)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &protocol.Socks{
+		Remark:  out.Tag,
+		Address: server.Address,
+		Port:    portString(server.Port),
+	}
+	if len(server.Users) != 0 {
+		s.Username = server.Users[0].User
+		s.Password = server.Users[0].Pass
+	}
+
+	return s, nil
+}
+
+func httpFromOutbound(out *conf.OutboundDetourConfig, s streamFields) (protocol.Protocol, error) {
+	server, err := firstServer(out)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &protocol.Http{
+		Remark:  out.Tag,
+		Address: strings.Trim(server.Address, "[]"),
+		Port:    portString(server.Port),
+	}
+	if len(server.Users) != 0 {
+		h.Username = server.Users[0].User
+		h.Password = server.Users[0].Pass
+	}
+	if s.Security == "tls" {
+		h.TLS = "tls"
+		h.SNI = s.SNI
+		h.TlsFingerprint = s.Fingerprint
+		if s.Insecure {
+			h.AllowInsecure = "1"
+		}
+	}
+
+	return h, nil
+}
+
+func wireguardFromOutbound(out *conf.OutboundDetourConfig) (protocol.Protocol, error) {
+	var settings struct {
+		SecretKey string   `json:"secretKey"`
+		Address   []string `json:"address"`
+		Peers     []Peer   `json:"peers"`
+		MTU       int32    `json:"mtu"`
+		Reserved  []int    `json:"reserved"`
+	}
+	if err := json.Unmarshal(*out.Settings, &settings); err != nil {
+		return nil, err
+	}
+	if len(settings.Peers) == 0 {
+		return nil, errors.New("wireguard outbound has no peer")
+	}
+
+	var reserved []string
+	for _, v := range settings.Reserved {
+		reserved = append(reserved, strconv.Itoa(v))
+	}
+
+	return &protocol.Wireguard{
+		Remark:       out.Tag,
+		PublicKey:    settings.Peers[0].PublicKey,
+		SecretKey:    settings.SecretKey,
+		PreSharedKey: settings.Peers[0].PreSharedKey,
+		Endpoint:     settings.Peers[0].Endpoint,
+		Reserved:     strings.Join(reserved, ","),
+		LocalAddress: strings.Join(settings.Address, ","),
+		Mtu:          settings.MTU,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package xray
+
+import "testing"
+
+func TestLoadOutbounds(t *testing.T) {
+	config := `{
+  // Comments are allowed in xray configs
+  "outbounds": [
+    {
+      "tag": "vless-reality",
+      "protocol": "vless",
+      "settings": {
+        "vnext": [
+          {
+            "address": "1.2.3.4",
+            "port": 443,
+            "users": [{ "id": "0090bbba-1118-46ca-87a1-52599cee74ab", "flow": "xtls-rprx-vision", "encryption": "none" }]
+          }
+        ]
+      },
+      "streamSettings": {
+        "network": "tcp",
+        "security": "reality",
+        "realitySettings": {
+          "serverName": "www.speedtest.net",
+          "fingerprint": "chrome",
+          "publicKey": "7xhH4b_VkliBxGulljcyPOH-bYUA2dl-XAdZAsfhk2U",
+          "shortId": "6ba85179e30d4fc2"
+        }
+      }
+    },
+    {
+      "tag": "trojan-ws",
+      "protocol": "trojan",
+      "settings": {
+        "servers": [{ "address": "example.com", "port": 443, "password": "secret" }]
+      },
+      "streamSettings": {
+        "network": "ws",
+        "security": "tls",
+        "tlsSettings": { "serverName": "example.com", "alpn": ["http/1.1"] },
+        "wsSettings": { "path": "/ws", "headers": { "Host": "example.com" } }
+      }
+    },
+    {
+      "tag": "http-tls",
+      "protocol": "http",
+      "settings": {
+        "servers": [{ "address": "proxy.example.com", "port": 8443, "users": [{ "user": "user", "pass": "pass" }] }]
+      },
+      "streamSettings": { "security": "tls", "tlsSettings": { "serverName": "proxy.example.com" } }
+    },
+    { "tag": "direct", "protocol": "freedom", "settings": {} }
+  ]
+}`
+
+	protocols, errs := LoadOutbounds([]byte(config))
+	if len(protocols) != 3 {
+		t.Fatalf("expected 3 outbounds, got %d (errors: %v)", len(protocols), errs)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected the freedom outbound to be reported, got %v", errs)
+	}
+
+	vless := protocols[0].(*Vless)
+	if vless.Address != "1.2.3.4" || vless.Port != "443" || vless.Security != "reality" || vless.Flow != "xtls-rprx-vision" || vless.PublicKey == "" {
+		t.Errorf("unexpected vless outbound: %+v", vless)
+	}
+
+	trojan := protocols[1].(*Trojan)
+	if trojan.Type != "ws" || trojan.Host != "example.com" || trojan.Path != "/ws" || trojan.SNI != "example.com" {
+		t.Errorf("unexpected trojan outbound: %+v", trojan)
+	}
+
+	h := protocols[2].(*Http)
+	if h.Username != "user" || h.Password != "pass" || h.TLS != "tls" || h.SNI != "proxy.example.com" || h.Port != "8443" {
+		t.Errorf("unexpected http outbound: %+v", h)
+	}
+
+	// The generated links must be parsable like any other link
+	for _, p := range protocols {
+		link := p.ToLink()
+		parsed, err := NewXrayService(false, false).CreateProtocol(link)
+		if err != nil {
+			t.Fatalf("%s: %v", link, err)
+		}
+		if err = parsed.Parse(); err != nil {
+			t.Errorf("%s: %v", link, err)
+		}
+		if _, err = parsed.(Protocol).BuildOutboundDetourConfig(false); err != nil {
+			t.Errorf("%s: %v", link, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package xray
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+// dialerTag is the tag of the freedom outbound the proxy outbound dials through when fragment or noises are set
+const dialerTag = "fragment"
+
+// Fragment is the fragmentation of the connections to the servers, done by a freedom outbound
+type Fragment struct {
+	Packets  string `json:"packets"`  // tlshello, or a range of TCP packets (e.g. 1-3)
+	Length   string `json:"length"`   // Range of the fragment lengths in bytes (e.g. 100-200)
+	Interval string `json:"interval"` // Range of the delays between the fragments in ms (e.g. 10-20)
+}
+
+// Noise is a UDP packet sent before the first packet of a connection, done by a freedom outbound
+type Noise struct {
+	Type   string `json:"type"` // rand, str, base64 or hex
+	Packet string `json:"packet"`
+	Delay  string `json:"delay"` // Range of the delays after the packet in ms (e.g. 10-16)
+}
+
+// ParseFragment parses "packets,length,interval" (e.g. "tlshello,100-200,10-20").
+// The empty parts take the defaults of tlshello, 100-200 and 10-20.
+func ParseFragment(s string) (Fragment, error) {
+	f := Fragment{Packets: "tlshello", Length: "100-200", Interval: "10-20"}
+
+	parts := strings.Split(s, ",")
+	if len(parts) > 3 {
+		return f, fmt.Errorf("invalid fragment %q, expected packets,length,interval", s)
+	}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		switch i {
+		case 0:
+			f.Packets = part
+		case 1:
+			f.Length = part
+		case 2:
+			f.Interval = part
+		}
+	}
+
+	if f.Packets != "tlshello" {
+		if from, _, err := conf.ParseRangeString(f.Packets); err != nil || from <= 0 {
+			return f, fmt.Errorf("invalid fragment packets %q, expected tlshello or a range like 1-3", f.Packets)
+		}
+	}
+	if from, _, err := conf.ParseRangeString(f.Length); err != nil || from <= 0 {
+		return f, fmt.Errorf("invalid fragment length %q, expected a range like 100-200", f.Length)
+	}
+	if from, _, err := conf.ParseRangeString(f.Interval); err != nil || from < 0 {
+		return f, fmt.Errorf("invalid fragment interval %q, expected a range like 10-20", f.Interval)
+	}
+	return f, nil
+}
+
+// ParseNoise parses "type,packet,delay" (e.g. "rand,10-20,10-16" or "str,hello,5").
+// The packet may contain commas, the delay defaults to 10-16.
+func ParseNoise(s string) (Noise, error) {
+	n := Noise{Delay: "10-16"}
+
+	first, last := strings.Index(s, ","), strings.LastIndex(s, ",")
+	switch {
+	case first == -1:
+		return n, fmt.Errorf("invalid noise %q, expected type,packet,delay", s)
+	case first == last:
+		n.Type, n.Packet = s[:first], s[first+1:]
+	default:
+		n.Type, n.Packet = s[:first], s[first+1:last]
+		if delay := strings.TrimSpace(s[last+1:]); delay != "" {
+			n.Delay = delay
+		}
+	}
+	n.Type = strings.TrimSpace(n.Type)
+
+	switch n.Type {
+	case "rand", "str", "base64", "hex":
+	default:
+		return n, fmt.Errorf("invalid noise type %q, expected rand, str, base64 or hex", n.Type)
+	}
+	if n.Packet == "" {
+		return n, fmt.Errorf("invalid noise %q, the packet is empty", s)
+	}
+	if _, _, err := conf.ParseRangeString(n.Delay); err != nil {
+		return n, fmt.Errorf("invalid noise delay %q, expected a range like 10-16", n.Delay)
+	}
+	return n, nil
+}
+
+// ParseAntiDPI returns the service options of a fragment (see ParseFragment) and noises (see ParseNoise),
+// as given on the command line. An empty fragment is no fragmentation.
+func ParseAntiDPI(fragment string, noises []string) ([]ServiceOption, error) {
+	var opts []ServiceOption
+	if fragment != "" {
+		f, err := ParseFragment(fragment)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithFragment(f))
+	}
+	for _, s := range noises {
+		n, err := ParseNoise(s)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithNoises(n))
+	}
+	return opts, nil
+}
+
+// WithFragment fragments the connections to the servers
+func WithFragment(fragment Fragment) ServiceOption {
+	return func(c *Core) {
+		c.Fragment = &fragment
+	}
+}
+
+// WithNoises sends noise packets before the UDP connections to the servers
+func WithNoises(noises ...Noise) ServiceOption {
+	return func(c *Core) {
+		c.Noises = append(c.Noises, noises...)
+	}
+}
+
+// SetOptions applies service options to an existing core
+func (c *Core) SetOptions(opts ...ServiceOption) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// dialerOutbound returns the freedom outbound applying the fragment and the noises,
+// nil when none is set. The proxy outbound dials through it with sockopt.dialerProxy.
+func (c *Core) dialerOutbound() (*conf.OutboundDetourConfig, error) {
+	if c.Fragment == nil && len(c.Noises) == 0 {
+		return nil, nil
+	}
+
+	settings := map[string]interface{}{}
+	if c.Fragment != nil {
+		settings["fragment"] = c.Fragment
+	}
+	if len(c.Noises) > 0 {
+		settings["noises"] = c.Noises
+	}
+	b, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(b)
+
+	return &conf.OutboundDetourConfig{
+		Protocol: "freedom",
+		Tag:      dialerTag,
+		Settings: &raw,
+	}, nil
+}
@@ -0,0 +1,75 @@
+package xray
+
+import "testing"
+
+func TestParseFragment(t *testing.T) {
+	f, err := ParseFragment("1-3,,5-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Fragment{Packets: "1-3", Length: "100-200", Interval: "5-10"}); f != want {
+		t.Errorf("got %+v, want %+v", f, want)
+	}
+
+	for _, s := range []string{"tlshello,0-10,10", "hello,100-200,10", "tlshello,100-200,10,20"} {
+		if _, err = ParseFragment(s); err == nil {
+			t.Errorf("%s: expected an error", s)
+		}
+	}
+}
+
+func TestParseNoise(t *testing.T) {
+	n, err := ParseNoise("str,hello, world,5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Noise{Type: "str", Packet: "hello, world", Delay: "5"}); n != want {
+		t.Errorf("got %+v, want %+v", n, want)
+	}
+
+	for _, s := range []string{"rand", "noise,10,5", "rand,,5"} {
+		if _, err = ParseNoise(s); err == nil {
+			t.Errorf("%s: expected an error", s)
+		}
+	}
+}
+
+func TestCore_Fragment(t *testing.T) {
+	f, _ := ParseFragment("tlshello")
+	n, _ := ParseNoise("rand,10-20,10-16")
+	c := NewXrayService(false, false, WithFragment(f), WithNoises(n))
+
+	v := NewVless("vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?type=tcp&security=tls&sni=example.com#fragment")
+	if err := v.Parse(); err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+	instance, err := c.MakeInstance(v)
+	if err != nil {
+		t.Fatalf("Error when making the instance: %v", err)
+	}
+	instance.Close()
+
+	// xray rejects the fragments of no length
+	c.SetOptions(WithFragment(Fragment{Packets: "tlshello", Length: "0", Interval: "10"}))
+	if _, err = c.MakeInstance(v); err == nil {
+		t.Error("expected an invalid fragment error")
+	}
+}
+
+func TestParseAntiDPI(t *testing.T) {
+	opts, err := ParseAntiDPI("tlshello", []string{"rand,10-20,10-16", "str,hello,5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewXrayService(false, false, opts...)
+	if c.Fragment == nil || len(c.Noises) != 2 {
+		t.Errorf("unexpected anti-DPI options: %+v %+v", c.Fragment, c.Noises)
+	}
+
+	if opts, err = ParseAntiDPI("", nil); err != nil || len(opts) != 0 {
+		t.Errorf("got %d options (%v), want none", len(opts), err)
+	}
+	if _, err = ParseAntiDPI("", []string{"noise"}); err == nil {
+		t.Error("expected an invalid noise error")
+	}
+}
@@ -0,0 +1,67 @@
+package xray
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/xtls/xray-core/infra/conf"
+	"strconv"
+)
+
+func NewHttp(link string) Protocol {
+	return &Http{protocol.NewHttp(link)}
+}
+
+func (h *Http) BuildOutboundDetourConfig(allowInsecure bool) (*conf.OutboundDetourConfig, error) {
+	out := &conf.OutboundDetourConfig{}
+	out.Tag = "proxy"
+	out.Protocol = "http"
+
+	p := conf.TransportProtocol("tcp")
+	s := &conf.StreamConfig{
+		Network:     &p,
+		TCPSettings: &conf.TCPConfig{},
+	}
+
+	if h.TLS == "tls" {
+		var insecure = allowInsecure
+		if h.AllowInsecure == "1" || h.AllowInsecure == "true" {
+			insecure = true
+		}
+
+		s.Security = "tls"
+		s.TLSSettings = &conf.TLSConfig{
+			ServerName:  h.SNI,
+			Fingerprint: h.TlsFingerprint,
+			Insecure:    insecure,
+		}
+	}
+	out.StreamSetting = s
+
+	port, err := strconv.Atoi(h.Port)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", h.Port)
+	}
+
+	server := map[string]interface{}{
+		"address": h.Address,
+		"port":    port,
+	}
+	if h.Username != "" {
+		server["users"] = []map[string]string{{"user": h.Username, "pass": h.Password}}
+	}
+	settings, err := json.Marshal(map[string]interface{}{
+		"servers": []interface{}{server},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	oset := json.RawMessage(settings)
+	out.Settings = &oset
+	return out, nil
+}
+
+func (h *Http) BuildInboundDetourConfig() (*conf.InboundDetourConfig, error) {
+	return nil, nil
+}
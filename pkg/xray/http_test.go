@@ -0,0 +1,29 @@
+package xray
+
+import (
+	"testing"
+)
+
+func TestHttp_Parse(t *testing.T) {
+	// Credentials encoded like socks links and the default https port
+	h := NewHttp("https://dXNlcjpwYXNz@proxy.example.com?sni=proxy.example.com#http")
+	if err := h.Parse(); err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+
+	hp := h.(*Http)
+	if hp.Username != "user" || hp.Password != "pass" || hp.Port != "443" || hp.TLS != "tls" || hp.SNI != "proxy.example.com" {
+		t.Errorf("unexpected http: %+v", hp)
+	}
+
+	out, err := h.BuildOutboundDetourConfig(false)
+	if err != nil {
+		t.Fatalf("Error when building outbound: %v", err)
+	}
+	// The settings have to be accepted by xray itself
+	if _, err = out.Build(); err != nil {
+		t.Errorf("Error when building xray outbound: %v", err)
+	}
+
+	t.Logf("%s\n", h.DetailsStr())
+}
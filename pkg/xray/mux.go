@@ -0,0 +1,20 @@
+package xray
+
+import (
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+// muxConfig returns the mux settings of an outbound, nil when the multiplexing is off.
+// Only vless, vmess, trojan and shadowsocks set it, plain socks and http servers don't speak mux.cool.
+func muxConfig(m protocol.Mux) *conf.MuxConfig {
+	if !m.Enabled {
+		return nil
+	}
+	return &conf.MuxConfig{
+		Enabled:         true,
+		Concurrency:     int16(m.Concurrency),
+		XudpConcurrency: int16(m.XudpConcurrency),
+		XudpProxyUDP443: m.XudpProxyUDP443,
+	}
+}
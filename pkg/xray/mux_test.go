@@ -0,0 +1,34 @@
+package xray
+
+import (
+	"testing"
+
+	"github.com/naser-989/xray-knife/v3/pkg/protocol"
+)
+
+func TestSocks_Mux(t *testing.T) {
+	protocol.SetMux(protocol.Mux{Enabled: true, Concurrency: 8})
+	defer protocol.SetMux(protocol.Mux{})
+
+	// Plain socks and http servers don't speak mux.cool, --mux leaves them alone
+	for _, link := range []string{"socks://dXNlcjpwYXNz@example.com:1080#socks", "http://example.com:8080#http"} {
+		p, err := protocol.CreateProtocol(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = p.Parse(); err != nil {
+			t.Fatalf("Error when parsing: %v", err)
+		}
+		built, err := Build(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := built.BuildOutboundDetourConfig(false)
+		if err != nil {
+			t.Fatalf("Error when building outbound: %v", err)
+		}
+		if out.MuxSettings != nil {
+			t.Errorf("%s: unexpected mux settings %+v", link, out.MuxSettings)
+		}
+	}
+}
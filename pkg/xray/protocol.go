@@ -1,36 +1,44 @@
 package xray
 
 import (
-	"errors"
+	"fmt"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
-	"net/url"
-	"strings"
 )
 
 func (c *Core) CreateProtocol(configLink string) (protocol.Protocol, error) {
-	// Remove any spaces
-	configLink = strings.TrimSpace(configLink)
-
-	// Parse url
-	uri, err := url.Parse(configLink)
+	p, err := protocol.CreateProtocol(configLink)
 	if err != nil {
 		return nil, err
 	}
 
-	switch uri.Scheme {
-	case protocol.VmessIdentifier:
-		return NewVmess(configLink), nil
-	case protocol.VlessIdentifier:
-		return NewVless(configLink), nil
-	case protocol.ShadowsocksIdentifier:
-		return NewShadowsocks(configLink), nil
-	case protocol.TrojanIdentifier:
-		return NewTrojan(configLink), nil
-	case protocol.SocksIdentifier:
-		return NewSocks(configLink), nil
-	case protocol.WireguardIdentifier:
-		return NewWireguard(configLink), nil
+	return c.Build(p)
+}
+
+// Build compiles a core-agnostic protocol into the xray protocol sharing its fields
+func (c *Core) Build(p protocol.Protocol) (protocol.Protocol, error) {
+	return Build(p)
+}
+
+// Build is Core.Build for the callers (like config loaders) that have no core
+func Build(p protocol.Protocol) (Protocol, error) {
+	switch v := p.(type) {
+	case *protocol.Vmess:
+		return &Vmess{v}, nil
+	case *protocol.Vless:
+		return &Vless{v}, nil
+	case *protocol.Shadowsocks:
+		return &Shadowsocks{v}, nil
+	case *protocol.Trojan:
+		return &Trojan{v}, nil
+	case *protocol.Socks:
+		return &Socks{v}, nil
+	case *protocol.Wireguard:
+		return &Wireguard{v}, nil
+	case *protocol.Http:
+		return &Http{v}, nil
+	case Protocol:
+		return v, nil
 	default:
-		return nil, errors.New("invalid xray protocol")
+		return nil, fmt.Errorf("%s protocol is not supported by xray core", p.ConvertToGeneralConfig().Protocol)
 	}
 }
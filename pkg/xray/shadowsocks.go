@@ -2,113 +2,29 @@ package xray
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
-	"net"
-	"net/url"
-	"strings"
 
-	"github.com/fatih/color"
-	"github.com/naser-989/xray-knife/v3/utils"
 	"github.com/xtls/xray-core/infra/conf"
 )
 
 func NewShadowsocks(link string) Protocol {
-	return &Shadowsocks{OrigLink: link}
+	return &Shadowsocks{protocol.NewShadowsocks(link)}
 }
 
-func (s *Shadowsocks) Name() string {
-	return "shadowsocks"
-}
-
-func (s *Shadowsocks) Parse() error {
-	if !strings.HasPrefix(s.OrigLink, protocol.ShadowsocksIdentifier) {
-		return fmt.Errorf("shadowsocks unreconized: %s", s.OrigLink)
-	}
-
-	uri, err := url.Parse(s.OrigLink)
-	if err != nil {
-		return err
-	}
-
-	secondPart := strings.SplitN(s.OrigLink[5:], "@", 2)
+func (s *Shadowsocks) Validate() protocol.Diagnostics {
+	d := s.Shadowsocks.Validate()
 
-	var decoded []byte
-	// Encryption part - b64 encoded (EncryptionType : Password)
-	if len(secondPart) > 1 {
-		decoded, err = utils.Base64Decode(secondPart[0])
-		if err != nil {
-			return errors.New("error when decoding secret part")
+	switch s.Plugin {
+	case "v2ray-plugin":
+		if mode := protocol.ParsePluginOpts(s.PluginOpts)["mode"]; mode != "" && mode != "websocket" {
+			d.Errorf("plugin", "v2ray-plugin %s mode is not supported by xray core", mode)
 		}
-	} else {
-		return errors.New("invalid config link")
+	case "obfs-local", "simple-obfs", "shadow-tls":
+		d.Errorf("plugin", "plugin %q is not supported by xray core, use the sing-box core", s.Plugin)
 	}
 
-	//link := "ss://" + string(decoded) + "@" + secondPart[1]
-	//uri, err := url.Parse(link)
-	//if err != nil {
-	//	return err
-	//}
-	creds := strings.SplitN(string(decoded), ":", 2)
-	if len(creds) != 2 {
-		return errors.New("error when decoding secret part")
-	}
-
-	s.Encryption = creds[0] // Encryption Type
-	s.Password = creds[1]   // Encryption Password
-
-	//hostPortRemark := strings.SplitN(secondPart[1], ":", 2)
-
-	s.Address, s.Port, err = net.SplitHostPort(uri.Host)
-	if err != nil {
-		return err
-	}
-
-	if utils.IsIPv6(s.Address) {
-		s.Address = "[" + s.Address + "]"
-	}
-
-	s.Remark, err = url.PathUnescape(uri.Fragment)
-	if err != nil {
-		s.Remark = uri.Fragment
-	}
-
-	//s.Address = hostPortRemark[0]
-	//
-	//PortRemark := strings.SplitN(hostPortRemark[1], "#", 2)
-	//s.Port = PortRemark[0]
-
-	//remarkStr, _, _ := strings.Cut(PortRemark[1], "\n")
-
-	//s.Remark, err = url.PathUnescape(remarkStr)
-	//if err != nil {
-	//	s.Remark = remarkStr
-	//}
-
-	return nil
-}
-
-func (s *Shadowsocks) DetailsStr() string {
-	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %v\n%s: %s\n%s: %s\n",
-		color.RedString("Protocol"), s.Name(),
-		color.RedString("Remark"), s.Remark,
-		color.RedString("IP"), s.Address,
-		color.RedString("Port"), s.Port,
-		color.RedString("Encryption"), s.Encryption,
-		color.RedString("Password"), s.Password)
-	return info
-}
-
-func (s *Shadowsocks) ConvertToGeneralConfig() (g protocol.GeneralConfig) {
-	g.Protocol = s.Name()
-	g.Address = s.Address
-	g.ID = s.Password
-	g.Port = s.Port
-	g.Remark = s.Remark
-	g.OrigLink = s.OrigLink
-
-	return g
+	return d
 }
 
 func (s *Shadowsocks) BuildOutboundDetourConfig(allowInsecure bool) (*conf.OutboundDetourConfig, error) {
@@ -118,6 +34,16 @@ func (s *Shadowsocks) BuildOutboundDetourConfig(allowInsecure bool) (*conf.Outbo
 
 	streamConf := &conf.StreamConfig{}
 
+	switch s.Plugin {
+	case "":
+	case "v2ray-plugin":
+		if err := s.v2rayPluginStream(streamConf, allowInsecure); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("shadowsocks plugin %q is not supported by xray core, use the sing-box core", s.Plugin)
+	}
+
 	out.StreamSetting = streamConf
 	oset := json.RawMessage([]byte(fmt.Sprintf(`{
   "servers": [
@@ -131,9 +57,47 @@ func (s *Shadowsocks) BuildOutboundDetourConfig(allowInsecure bool) (*conf.Outbo
   ]
 }`, s.Address, s.Port, s.Password, s.Encryption)))
 	out.Settings = &oset
+	out.MuxSettings = muxConfig(protocol.MuxOf(s.Shadowsocks))
 	return out, nil
 }
 
+// v2rayPluginStream expresses v2ray-plugin as the websocket transport it runs on.
+// Only the websocket mode has an xray equivalent.
+func (s *Shadowsocks) v2rayPluginStream(streamConf *conf.StreamConfig, allowInsecure bool) error {
+	pluginOpts := protocol.ParsePluginOpts(s.PluginOpts)
+
+	if mode, ok := pluginOpts["mode"]; ok && mode != "websocket" {
+		return fmt.Errorf("v2ray-plugin %s mode is not supported by xray core, use the sing-box core", mode)
+	}
+
+	// Same defaults as v2ray-plugin itself
+	host := "cloudfront.com"
+	if h := pluginOpts["host"]; h != "" {
+		host = h
+	}
+	path := "/"
+	if p := pluginOpts["path"]; p != "" {
+		path = p
+	}
+
+	network := conf.TransportProtocol("ws")
+	streamConf.Network = &network
+	streamConf.WSSettings = &conf.WebSocketConfig{
+		Path:    path,
+		Headers: map[string]string{"Host": host},
+	}
+
+	if _, ok := pluginOpts["tls"]; ok {
+		streamConf.Security = "tls"
+		streamConf.TLSSettings = &conf.TLSConfig{
+			ServerName: host,
+			Insecure:   allowInsecure,
+		}
+	}
+
+	return nil
+}
+
 func (s *Shadowsocks) BuildInboundDetourConfig() (*conf.InboundDetourConfig, error) {
 	return nil, nil
 }
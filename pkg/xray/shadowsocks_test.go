@@ -1,7 +1,43 @@
 package xray
 
-import "testing"
+import (
+	"testing"
+)
 
 func TestShadowSocks_Parse(t *testing.T) {
 	// "ss://YWVzLTI1Ni1nY206RXhhbXBsZUAxMjM0@example.com:443#exa"
 }
+
+func TestShadowsocks_Plugin(t *testing.T) {
+	ss := NewShadowsocks("ss://YWVzLTI1Ni1nY206c2VjcmV0@example.com:443/?plugin=v2ray-plugin%3Bmode%3Dwebsocket%3Btls%3Bhost%3Dcdn.example.com%3Bpath%3D%2Fws#v2ray-plugin")
+	if err := ss.Parse(); err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+
+	out, err := ss.BuildOutboundDetourConfig(false)
+	if err != nil {
+		t.Fatalf("Error when building outbound: %v", err)
+	}
+	stream := out.StreamSetting
+	if string(*stream.Network) != "ws" || stream.WSSettings.Path != "/ws" || stream.WSSettings.Headers["Host"] != "cdn.example.com" ||
+		stream.Security != "tls" || stream.TLSSettings.ServerName != "cdn.example.com" {
+		t.Errorf("unexpected stream settings: %+v", stream)
+	}
+	if _, err = out.Build(); err != nil {
+		t.Errorf("Error when building xray outbound: %v", err)
+	}
+
+	// Plugins without a transport equivalent must be reported instead of ignored
+	for _, link := range []string{
+		"ss://YWVzLTI1Ni1nY206c2VjcmV0@example.com:443/?plugin=obfs-local%3Bobfs%3Dhttp%3Bobfs-host%3Dwww.bing.com#obfs",
+		"ss://YWVzLTI1Ni1nY206c2VjcmV0@example.com:443/?plugin=v2ray-plugin%3Bmode%3Dquic#quic",
+	} {
+		ss = NewShadowsocks(link)
+		if err = ss.Parse(); err != nil {
+			t.Fatalf("Error when parsing: %v", err)
+		}
+		if _, err = ss.BuildOutboundDetourConfig(false); err == nil {
+			t.Errorf("%s: expected an unsupported plugin error", link)
+		}
+	}
+}
@@ -5,3 +5,33 @@ import "testing"
 func TestTrojan_Parse(t *testing.T) {
 	// "trojan://fsdfsgfgdfgdfg@1.1.1.1:80?flow=xtls-rprx-vision-udp443&security=tls&sni=example.com&alpn=h2%2Chttp%2F1.1&fp=chrome&type=grpc&serviceName=%2Fgdfgdgdfgdfgdfgfg&mode=gun#exa"
 }
+
+func TestTrojan_Mux(t *testing.T) {
+	tr := NewTrojan("trojan://pw@example.com:443?security=tls&mux=1&muxConcurrency=8&xudpConcurrency=16&xudpProxyUDP443=allow#mux")
+	if err := tr.Parse(); err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+
+	out, err := tr.BuildOutboundDetourConfig(false)
+	if err != nil {
+		t.Fatalf("Error when building outbound: %v", err)
+	}
+	if m := out.MuxSettings; m == nil || !m.Enabled || m.Concurrency != 8 || m.XudpConcurrency != 16 || m.XudpProxyUDP443 != "allow" {
+		t.Errorf("unexpected mux settings: %+v", m)
+	}
+	if _, err = out.Build(); err != nil {
+		t.Errorf("Error when building xray outbound: %v", err)
+	}
+
+	// Vision only multiplexes UDP
+	v := NewVless("vless://0090bbba-1118-46ca-87a1-52599cee74ab@example.com:443?security=tls&flow=xtls-rprx-vision&mux=1#vision")
+	if err = v.Parse(); err != nil {
+		t.Fatalf("Error when parsing: %v", err)
+	}
+	if out, err = v.BuildOutboundDetourConfig(false); err != nil {
+		t.Fatalf("Error when building outbound: %v", err)
+	}
+	if m := out.MuxSettings; m == nil || m.Concurrency != -1 {
+		t.Errorf("unexpected mux settings: %+v", m)
+	}
+}
@@ -4,227 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
-	"net"
-	"net/url"
 	"strings"
 
-	"github.com/fatih/color"
-	"github.com/naser-989/xray-knife/v3/utils"
 	"github.com/xtls/xray-core/infra/conf"
 )
 
 func NewVmess(link string) Protocol {
-	return &Vmess{OrigLink: link}
-}
-
-func (v *Vmess) Name() string {
-	return "vmess"
-}
-
-func method1(v *Vmess, link string) error {
-	b64encoded := link[8:]
-	decoded, err := utils.Base64Decode(b64encoded)
-	if err != nil {
-		return err
-	}
-	if err = json.Unmarshal(decoded, v); err != nil {
-		return err
-	}
-
-	if utils.IsIPv6(v.Address) {
-		v.Address = "[" + v.Address + "]"
-	}
-	return nil
-}
-
-// Example:
-// vmess://YXV0bzpjYmI0OTM1OC00NGQxLTQ4MmYtYWExNC02ODA3NzNlNWNjMzdAc25hcHBmb29kLmlyOjQ0Mw?remarks=sth&obfsParam=huhierg.com&path=/&obfs=websocket&tls=1&peer=gdfgreg.com&alterId=0
-func method2(v *Vmess, link string) error {
-	uri, err := url.Parse(link)
-	if err != nil {
-		return err
-	}
-	decoded, err := utils.Base64Decode(uri.Host)
-	if err != nil {
-		return err
-	}
-	link = protocol.VmessIdentifier + "://" + string(decoded) + "?" + uri.RawQuery
-
-	uri, err = url.Parse(link)
-	if err != nil {
-		return err
-	}
-
-	v.Security = uri.User.Username()
-	v.ID, _ = uri.User.Password()
-
-	v.Address, v.Port, err = net.SplitHostPort(uri.Host)
-	if err != nil {
-		return err
-	}
-
-	if utils.IsIPv6(v.Address) {
-		v.Address = "[" + v.Address + "]"
-	}
-	//parseUint, err := strconv.ParseUint(suhp[2], 10, 16)
-	//if err != nil {
-	//	return err
-	//}
-
-	v.Aid = "0"
-
-	queryValues := uri.Query()
-	if value := queryValues.Get("remarks"); value != "" {
-		v.Remark = value
-	}
-
-	if value := queryValues.Get("path"); value != "" {
-		v.Path = value
-	}
-
-	if value := queryValues.Get("tls"); value == "1" {
-		v.TLS = "tls"
-	}
-
-	if value := queryValues.Get("obfs"); value != "" {
-		switch value {
-		case "websocket":
-			v.Network = "ws"
-			v.Type = "none"
-		case "none":
-			v.Network = "tcp"
-			v.Type = "none"
-		}
-	}
-	if value := queryValues.Get("obfsParam"); value != "" {
-		v.Host = value
-	}
-	if value := queryValues.Get("peer"); value != "" {
-		v.SNI = value
-	} else {
-		if v.TLS == "tls" {
-			v.SNI = v.Host
-		}
-	}
-
-	return nil
-}
-
-//func method3(v *Vmess, link string) error {
-//
-//}
-
-func (v *Vmess) Parse() error {
-	if !strings.HasPrefix(v.OrigLink, protocol.VmessIdentifier) {
-		return fmt.Errorf("vmess unreconized: %s", v.OrigLink)
-	}
-
-	var err error = nil
-
-	if err = method1(v, v.OrigLink); err != nil {
-		if err = method2(v, v.OrigLink); err != nil {
-			return err
-		}
-	}
-
-	if v.Type == "xhttp" || v.Type == "http" || v.Network == "ws" || v.Network == "h2" {
-		if v.Path == "" {
-			v.Path = "/"
-		}
-	}
-
-	return err
-}
-
-func (v *Vmess) DetailsStr() string {
-	copyV := *v
-	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %v\n%s: %s\n",
-		color.RedString("Protocol"), v.Name(),
-		color.RedString("Remark"), copyV.Remark,
-		color.RedString("Network"), copyV.Network,
-		color.RedString("Address"), copyV.Address,
-		color.RedString("Port"), copyV.Port,
-		color.RedString("UUID"), copyV.ID)
-
-	if copyV.Network == "" {
-
-	} else if copyV.Type == "xhttp" || copyV.Type == "http" || copyV.Network == "httpupgrade" || copyV.Network == "ws" || copyV.Network == "h2" {
-		if copyV.Type == "" {
-			copyV.Type = "none"
-		}
-		if copyV.Host == "" {
-			copyV.Host = "none"
-		}
-		if copyV.Path == "" {
-			copyV.Path = "none"
-		}
-
-		info += fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n",
-			color.RedString("Type"), copyV.Type,
-			color.RedString("Host"), copyV.Host,
-			color.RedString("Path"), copyV.Path)
-	} else if copyV.Network == "kcp" {
-		info += fmt.Sprintf("%s: %s\n", color.RedString("KCP Seed"), copyV.Path)
-	} else if copyV.Network == "grpc" {
-		if copyV.Host == "" {
-			copyV.Host = "none"
-		}
-		info += fmt.Sprintf("%s: %s\n%s: %s\n",
-			color.RedString("ServiceName"), copyV.Path,
-			color.RedString("Authority"), copyV.Host)
-	}
-
-	if len(copyV.TLS) != 0 && copyV.TLS != "none" {
-		if len(copyV.SNI) == 0 {
-			if copyV.Host != "" {
-				copyV.SNI = copyV.Host
-			} else {
-				copyV.SNI = "none"
-			}
-		}
-		if len(copyV.ALPN) == 0 {
-			copyV.ALPN = "none"
-		}
-		if len(copyV.TlsFingerprint) == 0 {
-			copyV.TlsFingerprint = "none"
-		}
-		info += fmt.Sprintf("%s: tls\n%s: %s\n%s: %s\n%s: %s\n",
-			color.RedString("TLS"),
-			color.RedString("SNI"), copyV.SNI,
-			color.RedString("ALPN"), copyV.ALPN,
-			color.RedString("Fingerprint"), copyV.TlsFingerprint)
-
-		if v.AllowInsecure != "" {
-			info += fmt.Sprintf("%s: %v\n",
-				color.RedString("Insecure"), v.AllowInsecure)
-		}
-	}
-	return info
-}
-
-func (v *Vmess) ConvertToGeneralConfig() (g protocol.GeneralConfig) {
-	g.Protocol = v.Name()
-	g.Address = v.Address
-	g.Aid = fmt.Sprintf("%v", v.Aid)
-	g.Host = v.Host
-	g.ID = v.ID
-	g.Network = v.Network
-	g.Path = v.Path
-	g.Port = fmt.Sprintf("%v", v.Port)
-	g.Remark = v.Remark
-	if v.TLS == "" {
-		g.TLS = "none"
-	} else {
-		g.TLS = v.TLS
-	}
-	g.TLS = v.TLS
-	g.SNI = v.SNI
-	g.ALPN = v.ALPN
-	g.TlsFingerprint = v.TlsFingerprint
-	g.Type = v.Type
-	g.OrigLink = v.OrigLink
-
-	return g
+	return &Vmess{protocol.NewVmess(link)}
 }
 
 func (v *Vmess) BuildOutboundDetourConfig(allowInsecure bool) (*conf.OutboundDetourConfig, error) {
@@ -300,11 +86,6 @@ func (v *Vmess) BuildOutboundDetourConfig(allowInsecure bool) (*conf.OutboundDet
 		}
 		break
 	case "grpc":
-		if len(v.Path) > 0 {
-			if v.Path[0] == '/' {
-				v.Path = v.Path[1:]
-			}
-		}
 		multiMode := false
 		if v.Type != "gun" {
 			multiMode = true
@@ -315,7 +96,7 @@ func (v *Vmess) BuildOutboundDetourConfig(allowInsecure bool) (*conf.OutboundDet
 			MultiMode:          multiMode,
 			IdleTimeout:        60,
 			Authority:          v.Host,
-			ServiceName:        v.Path,
+			ServiceName:        strings.TrimPrefix(v.Path, "/"),
 		}
 		break
 		//case "quic":
@@ -332,11 +113,12 @@ func (v *Vmess) BuildOutboundDetourConfig(allowInsecure bool) (*conf.OutboundDet
 	}
 
 	if v.TLS == "tls" {
-		if v.TlsFingerprint == "" {
-			v.TlsFingerprint = "chrome"
+		fingerprint := v.TlsFingerprint
+		if fingerprint == "" {
+			fingerprint = "chrome"
 		}
 		s.TLSSettings = &conf.TLSConfig{
-			Fingerprint: v.TlsFingerprint,
+			Fingerprint: fingerprint,
 			Insecure:    allowInsecure,
 		}
 		if v.SNI != "" {
@@ -366,6 +148,7 @@ func (v *Vmess) BuildOutboundDetourConfig(allowInsecure bool) (*conf.OutboundDet
   ]
 }`, v.Address, v.Port, v.ID, v.Aid, v.Security))
 	out.Settings = &oset
+	out.MuxSettings = muxConfig(protocol.MuxOf(v.Vmess))
 	return out, nil
 }
 
@@ -2,97 +2,15 @@ package xray
 
 import (
 	"encoding/json"
-	"fmt"
-	"github.com/fatih/color"
+	"errors"
 	"github.com/naser-989/xray-knife/v3/pkg/protocol"
 	"github.com/xtls/xray-core/infra/conf"
-	"net/url"
-	"reflect"
+	"strconv"
 	"strings"
 )
 
 func NewWireguard(link string) Protocol {
-	return &Wireguard{OrigLink: link}
-}
-
-func (w *Wireguard) Name() string {
-	return "wireguard"
-}
-
-func (w *Wireguard) Parse() error {
-	if !strings.HasPrefix(w.OrigLink, protocol.WireguardIdentifier) {
-		return fmt.Errorf("wireguard unreconized: %s", w.OrigLink)
-	}
-
-	uri, err := url.Parse(w.OrigLink)
-	if err != nil {
-		return err
-	}
-
-	unescapedSecretKey, err0 := url.PathUnescape(uri.User.String())
-	if err0 != nil {
-		return err0
-	}
-
-	w.SecretKey = unescapedSecretKey
-
-	w.Endpoint = uri.Host
-
-	// Get the type of the struct
-	t := reflect.TypeOf(*w)
-
-	// Get the number of fields in the struct
-	numFields := t.NumField()
-
-	// Iterate over each field of the struct
-	for i := 0; i < numFields; i++ {
-		field := t.Field(i)
-		tag := field.Tag.Get("json")
-
-		// If the query value exists for the field, set it
-		if values, ok := uri.Query()[tag]; ok {
-			value := values[0]
-			v := reflect.ValueOf(w).Elem().FieldByName(field.Name)
-
-			switch v.Type().String() {
-			case "string":
-				v.SetString(value)
-			case "int32":
-				var intValue int
-				fmt.Sscanf(value, "%d", &intValue)
-				v.SetInt(int64(intValue))
-
-			}
-		}
-	}
-
-	w.Remark, err = url.PathUnescape(uri.Fragment)
-	if err != nil {
-		w.Remark = uri.Fragment
-	}
-
-	return nil
-}
-
-func (w *Wireguard) DetailsStr() string {
-	info := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %d\n%s: %s\n%s: %v\n%s: %s\n",
-		color.RedString("Protocol"), w.Name(),
-		color.RedString("Remark"), w.Remark,
-		color.RedString("Endpoint"), w.Endpoint,
-		color.RedString("MTU"), w.Mtu,
-		color.RedString("Local Addresses"), w.LocalAddress,
-		color.RedString("Public Key"), w.PublicKey,
-		color.RedString("Secret Key"), w.SecretKey,
-	)
-
-	return info
-}
-
-func (w *Wireguard) ConvertToGeneralConfig() (g protocol.GeneralConfig) {
-	g.Protocol = w.Name()
-	g.Address = w.Endpoint
-
-	return g
+	return &Wireguard{protocol.NewWireguard(link)}
 }
 
 type Peer struct {
@@ -106,6 +24,7 @@ type Config struct {
 	Address   []string `json:"address"`
 	Peers     []Peer   `json:"peers"`
 	MTU       int      `json:"mtu"`
+	Reserved  []int    `json:"reserved,omitempty"`
 }
 
 func (w *Wireguard) BuildOutboundDetourConfig(allowInsecure bool) (*conf.OutboundDetourConfig, error) {
@@ -161,6 +80,16 @@ func (w *Wireguard) BuildOutboundDetourConfig(allowInsecure bool) (*conf.Outboun
 		MTU: int(w.Mtu),
 	}
 
+	if w.Reserved != "" {
+		for _, v := range strings.Split(w.Reserved, ",") {
+			num, err := strconv.ParseUint(strings.TrimSpace(v), 10, 8)
+			if err != nil {
+				return nil, errors.New("invalid reserved value")
+			}
+			cfg.Reserved = append(cfg.Reserved, int(num))
+		}
+	}
+
 	jsonData, err := json.Marshal(cfg)
 	if err != nil {
 		return nil, err
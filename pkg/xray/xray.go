@@ -11,6 +11,7 @@ import (
 	xraynet "github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf"
 	"net"
 	"net/http"
 	"time"
@@ -30,12 +31,29 @@ type Core struct {
 	LogLevel commlog.Severity
 
 	AllowInsecure bool
+
+	// Anti-DPI of the connections to the servers, see WithFragment and WithNoises
+	Fragment *Fragment
+	Noises   []Noise
 }
 
 func (c *Core) Name() string {
 	return "xray"
 }
 
+// capabilities are the protocols and transports the outbound builders handle
+var capabilities = protocol.Capabilities{
+	Core: "xray",
+	Schemes: []string{protocol.VmessIdentifier, protocol.VlessIdentifier, protocol.ShadowsocksIdentifier, protocol.TrojanIdentifier,
+		protocol.SocksIdentifier, protocol.WireguardIdentifier, protocol.HttpIdentifier, protocol.HttpsIdentifier},
+	Transports: []string{"tcp", "ws", "grpc", "xhttp", "httpupgrade", "splithttp", "kcp"},
+	Security:   []string{"none", "tls", "reality"},
+}
+
+func (c *Core) Capabilities() protocol.Capabilities {
+	return capabilities
+}
+
 type ServiceOption = func(c *Core)
 
 func WithCustomLogLevel(logType applog.LogType, LogLevel commlog.Severity) ServiceOption {
@@ -75,17 +93,40 @@ func NewXrayService(verbose bool, allowInsecure bool, opts ...ServiceOption) *Co
 }
 
 func (c *Core) SetInbound(inbound protocol.Protocol) error {
-	c.Inbound = inbound.(Protocol)
+	in, err := Build(inbound)
+	if err != nil {
+		return err
+	}
+	c.Inbound = in
 	return nil
 }
 
 func (c *Core) MakeInstance(outbound protocol.Protocol) (protocol.Instance, error) {
-	out := outbound.(Protocol)
+	out, err := Build(outbound)
+	if err != nil {
+		return nil, err
+	}
+	if err = capabilities.Check(out); err != nil {
+		return nil, err
+	}
 
 	ob, err := out.BuildOutboundDetourConfig(c.AllowInsecure)
 	if err != nil {
 		return nil, err
 	}
+	dialer, err := c.dialerOutbound()
+	if err != nil {
+		return nil, err
+	}
+	if dialer != nil {
+		if ob.StreamSetting == nil {
+			ob.StreamSetting = &conf.StreamConfig{}
+		}
+		if ob.StreamSetting.SocketSettings == nil {
+			ob.StreamSetting.SocketSettings = &conf.SocketConfig{}
+		}
+		ob.StreamSetting.SocketSettings.DialerProxy = dialerTag
+	}
 	built, err1 := ob.Build()
 	if err1 != nil {
 		return nil, err1
@@ -115,7 +156,15 @@ func (c *Core) MakeInstance(outbound protocol.Protocol) (protocol.Instance, erro
 		}
 		clientConfig.Inbound = []*core.InboundHandlerConfig{ibcBuilt}
 	}
+	// The first outbound is the default one
 	clientConfig.Outbound = []*core.OutboundHandlerConfig{built}
+	if dialer != nil {
+		dialerBuilt, err := dialer.Build()
+		if err != nil {
+			return nil, fmt.Errorf("invalid fragment or noises: %w", err)
+		}
+		clientConfig.Outbound = append(clientConfig.Outbound, dialerBuilt)
+	}
 
 	server, err2 := core.New(clientConfig)
 	if err2 != nil {
@@ -125,8 +174,7 @@ func (c *Core) MakeInstance(outbound protocol.Protocol) (protocol.Instance, erro
 }
 
 func (c *Core) MakeHttpClient(outbound protocol.Protocol, maxDelay time.Duration) (*http.Client, protocol.Instance, error) {
-	out := outbound.(Protocol)
-	instance, err := c.MakeInstance(out)
+	instance, err := c.MakeInstance(outbound)
 	if err != nil {
 		return nil, nil, err
 	}